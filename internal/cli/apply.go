@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+var (
+	applyPrune bool
+	applyYes   bool
+)
+
+var applyCmd = &cobra.Command{
+	Use:          ApplyUse,
+	Short:        ApplyShort,
+	Long:         ApplyLong,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		proj := cfg.FindProject(name)
+		if proj == nil {
+			return fmt.Errorf(ErrProjectNotFound, name)
+		}
+
+		if !clientFor(proj).SessionExists(proj.Name) {
+			if err := CreateSession(proj); err != nil {
+				return err
+			}
+			fmt.Printf(OutputCreatedSession, proj.Name)
+			return nil
+		}
+
+		prune := applyPrune
+		if prune && !applyYes {
+			extra, err := extraWindows(proj)
+			if err != nil {
+				return err
+			}
+			if len(extra) > 0 {
+				prompt := fmt.Sprintf(PromptPrune, proj.Name, strings.Join(extra, OutputListSep))
+				prune = confirm(os.Stdin, prompt)
+			}
+		}
+
+		r, err := ApplyProject(proj, prune)
+		if err != nil {
+			return err
+		}
+		printReconciliation(proj.Name, r)
+		return nil
+	},
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&applyPrune, FlagPrune, false, FlagPruneDesc)
+	applyCmd.Flags().BoolVarP(&applyYes, FlagYes, FlagYesShort, false, FlagYesDesc)
+	rootCmd.AddCommand(applyCmd)
+}
+
+// ApplyProject brings the project's running session in line with its layout,
+// running on_start commands in the windows it adds. The session environment
+// is updated from the config first. With prune, windows the layout doesn't
+// have are killed.
+func ApplyProject(proj *config.Project, prune bool) (tmux.Reconciliation, error) {
+	if err := trustRepoCommands(proj); err != nil {
+		return tmux.Reconciliation{}, err
+	}
+	client := clientFor(proj)
+	// Windows added below start with the session's current env.
+	env, err := cfg.SessionEnv(proj)
+	if err == nil {
+		err = client.SetEnvironment(proj.Name, env)
+	}
+	if err != nil {
+		return tmux.Reconciliation{}, fmt.Errorf(ErrApplyingLayout, err)
+	}
+
+	r, err := client.ReconcileLayout(proj.Name, cfg.GetLayout(proj), proj.Path, prune)
+	if err != nil {
+		return r, fmt.Errorf(ErrApplyingLayout, err)
+	}
+	if len(r.Added) > 0 && len(proj.OnStart) > 0 {
+		if err := client.RunOnStart(r.Added, proj.OnStart, proj.Path); err != nil {
+			return r, fmt.Errorf(ErrRunningOnStart, err)
+		}
+	}
+	return r, nil
+}
+
+// extraWindows returns the names of the session's windows its layout doesn't have.
+func extraWindows(proj *config.Project) ([]string, error) {
+	drift, err := clientFor(proj).DiffSession(proj.Name, cfg.GetLayout(proj))
+	if err != nil {
+		return nil, fmt.Errorf(ErrDiffingSession, proj.Name, err)
+	}
+	var names []string
+	for _, w := range drift.Windows {
+		if w.Extra() {
+			names = append(names, w.LiveName)
+		}
+	}
+	return names, nil
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from in.
+// Anything but "y" or "yes" is a no. Stderr keeps the question visible when
+// stdout is captured, as in $(tplm open --print).
+func confirm(in io.Reader, prompt string) bool {
+	fmt.Fprint(os.Stderr, prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case AnswerY, AnswerYes:
+		return true
+	}
+	return false
+}
+
+func printReconciliation(name string, r tmux.Reconciliation) {
+	if !r.Changed() {
+		fmt.Printf(OutputInLayout, name)
+		return
+	}
+	for _, w := range r.Added {
+		fmt.Printf(OutputAddedWindow, w.Name)
+	}
+	windows := make([]string, 0, len(r.Split))
+	for window := range r.Split {
+		windows = append(windows, window)
+	}
+	sort.Strings(windows)
+	for _, window := range windows {
+		fmt.Printf(OutputAddedPanes, r.Split[window], window)
+	}
+	for _, window := range r.Pruned {
+		fmt.Printf(OutputPrunedWindow, window)
+	}
+}
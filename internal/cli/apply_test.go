@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+func TestApplyProject(t *testing.T) {
+	c := &config.Config{
+		Projects: []config.Project{{
+			Name: "api", Path: "/src/api", Layout: "dev",
+			OnStart: []config.OnStart{{Window: "server", Command: "go run ."}, {Window: "editor", Command: "nvim ."}},
+		}},
+		Layouts: map[string]config.Layout{
+			"dev": {Windows: []config.Window{{Name: "editor"}, {Name: "server"}}},
+		},
+	}
+	fake := useFake(t, c)
+	s := fake.AddSession("api", "/src/api", "scratch", "editor")
+
+	r, err := ApplyProject(c.FindProject("api"), false)
+	if err != nil {
+		t.Fatalf("ApplyProject() error = %v", err)
+	}
+	if len(r.Added) != 1 || r.Added[0].Name != "server" {
+		t.Fatalf("Added = %+v, want server", r.Added)
+	}
+	// on_start runs only in the window that was added.
+	if keys := s.Window("server").Panes[0].Keys; len(keys) != 1 || keys[0] != "go run . Enter" {
+		t.Errorf("server keys = %q, want go run .", keys)
+	}
+	if keys := s.Window("editor").Panes[0].Keys; len(keys) != 0 {
+		t.Errorf("existing editor window got keys %q", keys)
+	}
+	if s.Window("scratch") == nil {
+		t.Error("scratch removed without prune")
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	for answer, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false} {
+		if got := confirm(strings.NewReader(answer), ""); got != want {
+			t.Errorf("confirm(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
@@ -10,36 +10,116 @@ const (
 	PickerShort = "Open the interactive project picker"
 	PickerLong  = "Opens a Bubbletea TUI for browsing and switching between projects and sessions.\nIntended to run inside tmux display-popup."
 
-	OpenUse   = "open <project-name>"
+	OpenUse   = "open [project-name | dir]"
 	OpenShort = "Create a session from project config and switch to it"
+	OpenLong  = "Creates the project's session if it doesn't exist, then switches to it when run inside tmux\nor attaches to it when run from a plain terminal. A directory, or none for the current one,\nopens the project with that path, which the directory's .tplm.yaml can define."
 
 	ListUse   = "list"
 	ListShort = "Print projects and active tmux sessions"
 
 	InitUse   = "init"
 	InitShort = "Generate a starter config file"
+
+	ValidateUse   = "validate"
+	ValidateShort = "Check the config file for problems"
+	ValidateLong  = "Reports every problem in the config file with its file:line:column position.\nExits non-zero when any problem is found, so it can run in pre-commit hooks."
+
+	StatusUse   = "status"
+	StatusShort = "Show each project's session and how it differs from its layout"
+	StatusLong  = "Lists every configured project with whether its session is running and attached (*),\nand summarizes windows that are missing, extra, renamed or have a different pane count."
+
+	DiffUse   = "diff <project-name>"
+	DiffShort = "Compare a project's running session with its layout, window by window"
+	DiffLong  = "Reports each window of the project's layout and of its running session:\n  =  matches the layout\n  -  missing from the session\n  +  extra, not in the layout\n  ~  renamed or with a different pane count\nExits non-zero when the session differs from its layout."
+
+	ApplyUse   = "apply <project-name>"
+	ApplyShort = "Bring a running session back in line with its layout"
+	ApplyLong  = "Adds the windows the project's session is missing and splits off missing panes,\nrunning commands only in what it creates. Existing panes are left alone.\nWith --prune, windows that aren't in the layout are killed after confirmation.\nA project without a running session gets one, as with tplm open --detached."
+
+	RestartUse   = "restart <project-name>"
+	RestartShort = "Kill a project's session and create it again from its layout"
+	RestartLong  = "Rebuilds the project's session from its layout and on_start commands, then focuses\nthe window and pane that had focus before. If you are in the session, tmux shows\nanother session during the rebuild and switches back afterwards."
+
+	StopUse   = "stop <project-name>"
+	StopShort = "Run a project's on_stop steps, then kill its session"
+	StopLong  = "Runs the project's on_stop steps in order (sending keys such as C-c to a window,\nwaiting for a window's panes to exit, running shell commands in the project path)\nand then kills its session. If a step fails, the session is left running."
+
+	SaveUse   = "save <session>"
+	SaveShort = "Snapshot a running session into a layout"
+	SaveLong  = "Inspects a live session's windows, pane splits, working directories and running commands\nand prints the equivalent layout as YAML, or merges it into the config file with --write.\nComments in the config file are kept."
+
+	TrustUse   = "trust [project-name | dir]"
+	TrustShort = "Trust the commands a project's .tplm.yaml runs"
+	TrustLong  = "Lists the commands, vars and env the project's .tplm.yaml sets and records the file\nas trusted, so opening the project uses them without asking. Trust lasts until the file changes."
 )
 
 // Flag names.
-const FlagConfig = "config"
+const (
+	FlagConfig          = "config"
+	FlagSocketName      = "socket-name"
+	FlagSocketNameShort = "L"
+	FlagSocketPath      = "socket-path"
+	FlagSocketPathShort = "S"
+	FlagDetached        = "detached"
+	FlagPrint           = "print"
+	FlagQuery           = "query"
+	FlagAsLayout        = "as-layout"
+	FlagProject         = "project"
+	FlagWrite           = "write"
+	FlagPrune           = "prune"
+	FlagYes             = "yes"
+	FlagYesShort        = "y"
+	FlagRefresh         = "refresh"
+)
 
 // Flag descriptions.
-const FlagConfigDesc = "path to config file"
+const (
+	FlagConfigDesc     = "path to config file"
+	FlagSocketNameDesc = "tmux server socket name (like tmux -L); overrides tmux_socket in the config"
+	FlagSocketPathDesc = "tmux server socket path (like tmux -S); overrides tmux_socket in the config"
+	FlagDetachedDesc   = "only create the session; don't switch or attach"
+	FlagPrintDesc      = "only create the session and print its name, for scripts that attach themselves"
+	FlagQueryDesc      = "start with the filter pre-filled with this query"
+	FlagAsLayoutDesc   = "name of the saved layout (default: the session name)"
+	FlagProjectDesc    = "also save a project with this name that uses the layout"
+	FlagWriteDesc      = "merge into the config file instead of printing"
+	FlagPruneDesc      = "also kill windows that aren't in the layout"
+	FlagYesDesc        = "don't ask before pruning windows"
+	FlagRefreshDesc    = "scan the discover roots again instead of using the cached scan"
+)
+
+// CurrentDir is the directory tplm open and tplm trust use without an argument.
+const CurrentDir = "."
 
 // Command names used for skipping config load.
-const CmdInit = "init"
+const (
+	CmdInit     = "init"
+	CmdValidate = "validate"
+	CmdPicker   = "picker"
+)
 
 // Error message templates.
 const (
-	ErrLoadingConfig    = "loading config: %w\nRun 'tplm init' to create a starter config"
-	ErrRunningPicker    = "running picker: %w"
-	ErrProjectNotFound  = "project %q not found in config"
-	ErrCreatingSession  = "creating session: %w"
-	ErrApplyingLayout   = "applying layout: %w"
-	ErrRunningOnStart   = "running on_start: %w"
-	ErrCreatingDir      = "creating config directory: %w"
-	ErrConfigExists     = "config already exists at %s"
-	ErrWritingConfig    = "writing config: %w"
+	ErrLoadingConfig     = "loading config: %w\nRun 'tplm init' to create a starter config"
+	ErrRunningPicker     = "running picker: %w"
+	ErrProjectNotFound   = "project %q not found in config"
+	ErrCreatingSession   = "creating session: %w"
+	ErrApplyingLayout    = "applying layout: %w"
+	ErrRunningOnStart    = "running on_start: %w"
+	ErrRestartingSession = "restarting session: %w"
+	ErrKillingSession    = "killing session: %w"
+	ErrCreatingDir       = "creating config directory: %w"
+	ErrConfigExists      = "config already exists at %s"
+	ErrWritingConfig     = "writing config: %w"
+	ErrValidatingConfig  = "validating config: %w"
+	ErrInvalidConfig     = "config has %d problem(s)"
+	ErrSocketPath        = "resolving socket path: %w"
+	ErrSessionNotFound   = "session %q not found"
+	ErrSessionNotRunning = "project %q has no running session"
+	ErrDiffingSession    = "comparing session %q with its layout: %w"
+	ErrSessionDrift      = "session %q differs from its layout"
+	ErrSnapshotSession   = "reading session: %w"
+	ErrReadingConfig     = "reading config: %w"
 )
 
 // User-facing output strings.
@@ -48,8 +128,56 @@ const (
 	OutputActiveSessions = "Active Sessions:"
 	OutputNone           = "  (none)"
 	OutputCreatedConfig  = "Created starter config at %s\n"
+	OutputConfigValid    = "%s: OK\n"
+	OutputSavedLayout    = "Saved layout %q to %s\n"
 	OutputAttached       = "*"
 	OutputNotAttached    = " "
 	FmtListProject       = "  %-20s %s\n"
 	FmtListSession       = "  %s %-20s %d windows\n"
+	FmtStatusLine        = "  %s %-20s %-11s %s\n"
+	OutputRunning        = "running"
+	OutputNotRunning     = "not running"
+	OutputInSync         = "in sync"
+	OutputListSep        = ", "
+	FmtDriftMissing      = "%d missing"
+	FmtDriftExtra        = "%d extra"
+	FmtDriftRenamed      = "%d renamed"
+	FmtDriftPanes        = "%d with other pane counts"
+	FmtDiffHeader        = "%s (layout %s):\n"
+	FmtDiffLine          = "  %s %-20s %s\n"
+	OutputDefaultLayout  = "(default)"
+	OutputWindowMissing  = "missing"
+	OutputNoteSep        = "; "
+	FmtWindowExtra       = "extra, %d pane(s)"
+	FmtWindowPanes       = "%d pane(s)"
+	FmtWindowRenamed     = "renamed to %q"
+	FmtWindowPaneCount   = "%d pane(s), layout has %d"
+	OutputCreatedSession = "Created session %s\n"
+	OutputInLayout       = "%s already matches its layout\n"
+	OutputAddedWindow    = "Added window %s\n"
+	OutputAddedPanes     = "Added %d pane(s) to window %s\n"
+	OutputPrunedWindow   = "Removed window %s\n"
+	OutputRestarted      = "Restarted session %s\n"
+	OutputStopped        = "Stopped session %s\n"
+	OutputWarning        = "warning: %v\n"
+	PromptPrune          = "Kill windows of %s that aren't in its layout (%s)? [y/N] "
+	PromptTrust          = "Trust the commands in %s? [y/N] "
+	OutputRepoCommands   = "%s runs:\n"
+	FmtRepoCommand       = "  %s\n"
+	OutputTrusted        = "Trusted %s\n"
+	OutputNoRepoCommands = "%s runs no commands from a %s\n"
+)
+
+// Accepted answers to yes/no prompts, lowercased.
+const (
+	AnswerY   = "y"
+	AnswerYes = "yes"
+)
+
+// Markers in front of each window in tplm diff.
+const (
+	DiffMarkerSame    = "="
+	DiffMarkerMissing = "-"
+	DiffMarkerExtra   = "+"
+	DiffMarkerChanged = "~"
 )
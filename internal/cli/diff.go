@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+var diffCmd = &cobra.Command{
+	Use:          DiffUse,
+	Short:        DiffShort,
+	Long:         DiffLong,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		proj := cfg.FindProject(name)
+		if proj == nil {
+			return fmt.Errorf(ErrProjectNotFound, name)
+		}
+		if !clientFor(proj).SessionExists(proj.Name) {
+			return fmt.Errorf(ErrSessionNotRunning, proj.Name)
+		}
+
+		drift, err := clientFor(proj).DiffSession(proj.Name, cfg.GetLayout(proj))
+		if err != nil {
+			return fmt.Errorf(ErrDiffingSession, proj.Name, err)
+		}
+
+		layout := proj.Layout
+		if layout == "" {
+			layout = OutputDefaultLayout
+		}
+		fmt.Printf(FmtDiffHeader, proj.Name, layout)
+		for _, w := range drift.Windows {
+			marker, name := DiffMarkerSame, w.Name
+			switch {
+			case w.Missing():
+				marker = DiffMarkerMissing
+			case w.Extra():
+				marker, name = DiffMarkerExtra, w.LiveName
+			case !w.InSync():
+				marker = DiffMarkerChanged
+			}
+			fmt.Printf(FmtDiffLine, marker, name, windowReport(w))
+		}
+
+		if !drift.InSync() {
+			return fmt.Errorf(ErrSessionDrift, proj.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+// windowReport describes how one window compares with the layout.
+func windowReport(w tmux.WindowDiff) string {
+	switch {
+	case w.Missing():
+		return OutputWindowMissing
+	case w.Extra():
+		return fmt.Sprintf(FmtWindowExtra, w.GotPanes)
+	case w.InSync():
+		return fmt.Sprintf(FmtWindowPanes, w.GotPanes)
+	}
+
+	var notes []string
+	if w.Renamed() {
+		notes = append(notes, fmt.Sprintf(FmtWindowRenamed, w.LiveName))
+	}
+	if w.PanesDiffer() {
+		notes = append(notes, fmt.Sprintf(FmtWindowPaneCount, w.GotPanes, w.WantPanes))
+	}
+	return strings.Join(notes, OutputNoteSep)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+// useTestServer points the package-level client at a private tmux server and
+// installs cfg for the duration of the test.
+func useTestServer(t *testing.T, c *config.Config) *tmuxtest.Server {
+	t.Helper()
+	srv := tmuxtest.StartServer(t)
+	prevClient, prevCfg := client, cfg
+	client, cfg = tmux.NewClient(tmux.ExecRunner{SocketName: srv.SocketName}), c
+	t.Cleanup(func() { client, cfg = prevClient, prevCfg })
+	return srv
+}
+
+func TestIntegrationCreateSession(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Config{
+		Projects: []config.Project{{Name: "api", Path: dir, Layout: "dev"}},
+		Layouts: map[string]config.Layout{
+			"dev": {Windows: []config.Window{
+				{Name: "editor", Panes: []config.Pane{{}, {Split: "vertical", Size: "30%"}}},
+				{Name: "server"},
+			}},
+		},
+	}
+	srv := useTestServer(t, c)
+
+	if err := CreateSession(c.FindProject("api")); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	panes := srv.Panes("api")
+	if len(panes) != 3 {
+		t.Fatalf("got %d panes, want 3: %+v", len(panes), panes)
+	}
+	if panes[0].WindowName != "editor" || panes[1].WindowName != "editor" || panes[2].WindowName != "server" {
+		t.Errorf("unexpected windows: %+v", panes)
+	}
+	if panes[1].Top <= panes[0].Top || panes[1].Left != panes[0].Left {
+		t.Errorf("editor pane 1 not split vertically: %+v", panes[:2])
+	}
+	for _, p := range panes[:2] {
+		if p.Path != dir {
+			t.Errorf("pane %d path = %q, want %q", p.Index, p.Path, dir)
+		}
+	}
+}
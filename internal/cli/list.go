@@ -4,19 +4,26 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/rmvaldesd/tplm/internal/tmux"
 )
 
+var listRefresh bool
+
 var listCmd = &cobra.Command{
 	Use:   ListUse,
 	Short: ListShort,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if listRefresh {
+			if err := cfg.RefreshDiscovered(); err != nil {
+				return err
+			}
+		}
+
 		fmt.Println(OutputProjects)
 		for _, p := range cfg.Projects {
 			fmt.Printf(FmtListProject, p.Name, p.Path)
 		}
 
-		sessions, err := tmux.ListSessions()
+		sessions, err := client.ListSessions()
 		if err != nil {
 			return err
 		}
@@ -39,5 +46,6 @@ var listCmd = &cobra.Command{
 }
 
 func init() {
+	listCmd.Flags().BoolVar(&listRefresh, FlagRefresh, false, FlagRefreshDesc)
 	rootCmd.AddCommand(listCmd)
 }
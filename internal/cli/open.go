@@ -2,53 +2,112 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/rmvaldesd/tplm/internal/config"
 	"github.com/rmvaldesd/tplm/internal/tmux"
 )
 
+var (
+	openDetached bool
+	openPrint    bool
+)
+
 var openCmd = &cobra.Command{
 	Use:   OpenUse,
 	Short: OpenShort,
-	Args:  cobra.ExactArgs(1),
+	Long:  OpenLong,
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		name := args[0]
+		proj, err := projectOrDir(args)
+		if err != nil {
+			return err
+		}
 
-		proj := cfg.FindProject(name)
-		if proj == nil {
-			return fmt.Errorf(ErrProjectNotFound, name)
+		if !openDetached && !openPrint {
+			return OpenProject(proj)
 		}
 
-		return OpenProject(proj)
+		if err := CreateSession(proj); err != nil {
+			return err
+		}
+		if openPrint {
+			fmt.Println(proj.Name)
+		}
+		return nil
 	},
 }
 
 func init() {
+	openCmd.Flags().BoolVar(&openDetached, FlagDetached, false, FlagDetachedDesc)
+	openCmd.Flags().BoolVar(&openPrint, FlagPrint, false, FlagPrintDesc)
 	rootCmd.AddCommand(openCmd)
 }
 
-// OpenProject creates a tmux session for the project (if needed) and switches to it.
+// projectOrDir returns the project named by the argument, or else the one for
+// the directory it names, which the directory's .tplm.yaml can define. Without
+// an argument it is the current directory's.
+func projectOrDir(args []string) (*config.Project, error) {
+	arg := CurrentDir
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	if proj := cfg.FindProject(arg); proj != nil {
+		return proj, nil
+	}
+	if info, err := os.Stat(arg); err == nil && info.IsDir() {
+		return cfg.ProjectForDir(arg)
+	}
+	return nil, fmt.Errorf(ErrProjectNotFound, arg)
+}
+
+// OpenProject creates a tmux session for the project (if needed) and moves the
+// user to it: it switches the client when run inside tmux and attaches the
+// terminal otherwise.
 func OpenProject(proj *config.Project) error {
-	if tmux.SessionExists(proj.Name) {
-		return tmux.SwitchClient(proj.Name)
+	if err := CreateSession(proj); err != nil {
+		return err
+	}
+	return clientFor(proj).SwitchOrAttach(proj.Name)
+}
+
+// CreateSession runs the project's before_start commands, then creates a
+// detached tmux session for it from its layout and on_start commands. It does
+// nothing if the session already exists. Commands from the project's
+// .tplm.yaml only run once the user trusts them.
+func CreateSession(proj *config.Project) error {
+	client := clientFor(proj)
+	if client.SessionExists(proj.Name) {
+		return nil
 	}
 
-	if err := tmux.NewSession(proj.Name, proj.Path); err != nil {
+	if err := trustRepoCommands(proj); err != nil {
+		return err
+	}
+	if err := tmux.RunBeforeStart(proj.Path, proj.BeforeStart); err != nil {
+		return err
+	}
+
+	env, err := cfg.SessionEnv(proj)
+	if err != nil {
+		return fmt.Errorf(ErrCreatingSession, err)
+	}
+	if err := client.NewSession(proj.Name, proj.Path, env.Pairs()...); err != nil {
 		return fmt.Errorf(ErrCreatingSession, err)
 	}
 
 	layout := cfg.GetLayout(proj)
 
-	if err := tmux.ApplyLayout(proj.Name, layout, proj.Path); err != nil {
+	windows, err := client.ApplyLayout(proj.Name, layout, proj.Path)
+	if err != nil {
 		return fmt.Errorf(ErrApplyingLayout, err)
 	}
 
 	if len(proj.OnStart) > 0 {
-		if err := tmux.RunOnStart(proj.Name, layout, proj.OnStart); err != nil {
+		if err := client.RunOnStart(windows, proj.OnStart, proj.Path); err != nil {
 			return fmt.Errorf(ErrRunningOnStart, err)
 		}
 	}
-
-	return tmux.SwitchClient(proj.Name)
+	return nil
 }
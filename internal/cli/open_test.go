@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+// useFake points the package-level client at a fake tmux server and installs
+// cfg for the duration of the test.
+func useFake(t *testing.T, c *config.Config) *tmuxtest.Fake {
+	t.Helper()
+	fake := tmuxtest.New()
+	prevClient, prevCfg := client, cfg
+	client, cfg = tmux.NewClient(fake), c
+	t.Cleanup(func() { client, cfg = prevClient, prevCfg })
+	return fake
+}
+
+func TestOpenProject(t *testing.T) {
+	c := &config.Config{Projects: []config.Project{{Name: "api", Path: "/src/api"}}}
+
+	tests := []struct {
+		name     string
+		tmuxEnv  string
+		existing bool
+		wantCmd  string
+	}{
+		{name: "outside tmux attaches", wantCmd: "attach-session -t api"},
+		{name: "inside tmux switches", tmuxEnv: "/tmp/tmux-1000/default,1,0", wantCmd: "switch-client -t api"},
+		{name: "existing session is reused", tmuxEnv: "/tmp/tmux-1000/default,1,0", existing: true, wantCmd: "switch-client -t api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tmux.EnvTmux, tt.tmuxEnv)
+			fake := useFake(t, c)
+			fake.AddSession("scratch", "/tmp")
+			if tt.tmuxEnv != "" {
+				fake.Client = "scratch"
+			}
+			if tt.existing {
+				fake.AddSession("api", "/src/api", "editor")
+			}
+
+			if err := OpenProject(c.FindProject("api")); err != nil {
+				t.Fatalf("OpenProject() error = %v", err)
+			}
+
+			cmds := fake.Commands()
+			if got := cmds[len(cmds)-1]; got != tt.wantCmd {
+				t.Errorf("last command = %q, want %q", got, tt.wantCmd)
+			}
+			if fake.Client != "api" {
+				t.Errorf("client on %q, want api", fake.Client)
+			}
+			if tt.existing && fake.Session("api").Windows[0].Name != "editor" {
+				t.Error("existing session was rebuilt")
+			}
+		})
+	}
+}
+
+func TestCreateSessionDetached(t *testing.T) {
+	c := &config.Config{Projects: []config.Project{{Name: "api", Path: "/src/api"}}}
+	fake := useFake(t, c)
+
+	if err := CreateSession(c.FindProject("api")); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if fake.Session("api") == nil {
+		t.Fatal("session api not created")
+	}
+	for _, cmd := range fake.Commands() {
+		if cmd == "attach-session -t api" || cmd == "switch-client -t api" {
+			t.Errorf("CreateSession() moved the client: %q", cmd)
+		}
+	}
+}
+
+func TestCreateSessionBeforeStart(t *testing.T) {
+	dir := t.TempDir()
+	c := &config.Config{Projects: []config.Project{{
+		Name: "api", Path: dir,
+		BeforeStart: []string{"touch ready", "echo port in use >&2; exit 1", "touch unreachable"},
+	}}}
+	fake := useFake(t, c)
+
+	err := CreateSession(c.FindProject("api"))
+	if err == nil || !strings.Contains(err.Error(), "port in use") {
+		t.Fatalf("CreateSession() error = %v, want the failing hook's output", err)
+	}
+	if fake.Session("api") != nil {
+		t.Error("session created despite the failing hook")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ready")); err != nil {
+		t.Errorf("first hook did not run in the project path: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unreachable")); err == nil {
+		t.Error("hook after the failing one ran")
+	}
+}
+
+func TestCreateSessionEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_URL=postgres://localhost/api\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Config{Projects: []config.Project{{
+		Name: "api", Path: dir,
+		Env: config.Env{"APP_ENV": "dev"}, EnvFile: []string{".env"},
+	}}}
+	fake := useFake(t, c)
+
+	if err := CreateSession(c.FindProject("api")); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	want := map[string]string{"APP_ENV": "dev", "DB_URL": "postgres://localhost/api"}
+	if got := fake.Session("api").Env; !reflect.DeepEqual(got, want) {
+		t.Errorf("session env = %v, want %v", got, want)
+	}
+}
+
+func TestOpenRepoDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "my-api")
+	repo := "windows:\n  - name: editor\n  - name: server\n    panes:\n      - command: make run\n"
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.RepoFileName), []byte(repo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fake := useFake(t, &config.Config{})
+	t.Chdir(dir)
+
+	proj, err := projectOrDir(nil)
+	if err != nil {
+		t.Fatalf("projectOrDir() error = %v", err)
+	}
+	if proj.Name != "my-api" {
+		t.Errorf("project name = %q, want the directory's", proj.Name)
+	}
+
+	prevInput := trustInput
+	t.Cleanup(func() { trustInput = prevInput })
+
+	trustInput = strings.NewReader("n\n")
+	if err := CreateSession(proj); !errors.Is(err, config.ErrUntrusted) {
+		t.Fatalf("CreateSession() declined = %v, want ErrUntrusted", err)
+	}
+	if fake.Session("my-api") != nil {
+		t.Fatal("session created without trust")
+	}
+
+	// The prompt goes to stderr, so $(tplm open --print) captures only the name.
+	trustInput = strings.NewReader("y\n")
+	stdout := captureStdout(t, func() {
+		if err := CreateSession(proj); err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+	})
+	if stdout != "" {
+		t.Errorf("trust prompt wrote %q to stdout", stdout)
+	}
+	s := fake.Session("my-api")
+	if s == nil || len(s.Windows) != 2 || s.Windows[1].Name != "server" {
+		t.Fatalf("session = %+v, want the .tplm.yaml windows", s)
+	}
+
+	// Trusted now: no prompt, so an empty answer doesn't matter.
+	trustInput = strings.NewReader("")
+	if err := StopProject(proj); err != nil {
+		t.Errorf("StopProject() after trusting = %v", err)
+	}
+}
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = prev }()
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
@@ -8,12 +8,17 @@ import (
 	"github.com/rmvaldesd/tplm/internal/ui"
 )
 
+var pickerQuery string
+
 var pickerCmd = &cobra.Command{
 	Use:   PickerUse,
 	Short: PickerShort,
 	Long:  PickerLong,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		m := ui.NewPicker(cfg)
+		m := ui.NewPicker(cfg, client)
+		if pickerQuery != "" {
+			m = m.WithQuery(pickerQuery)
+		}
 		p := tea.NewProgram(m, tea.WithAltScreen())
 		if _, err := p.Run(); err != nil {
 			return fmt.Errorf(ErrRunningPicker, err)
@@ -23,5 +28,6 @@ var pickerCmd = &cobra.Command{
 }
 
 func init() {
+	pickerCmd.Flags().StringVar(&pickerQuery, FlagQuery, "", FlagQueryDesc)
 	rootCmd.AddCommand(pickerCmd)
 }
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+var restartCmd = &cobra.Command{
+	Use:          RestartUse,
+	Short:        RestartShort,
+	Long:         RestartLong,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		proj := cfg.FindProject(name)
+		if proj == nil {
+			return fmt.Errorf(ErrProjectNotFound, name)
+		}
+
+		if err := RestartProject(proj); err != nil {
+			return err
+		}
+		fmt.Printf(OutputRestarted, proj.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restartCmd)
+}
+
+// RestartProject kills the project's session and creates it again from its
+// layout and on_start commands, keeping the focused window and pane. A project
+// without a running session just gets one.
+func RestartProject(proj *config.Project) error {
+	// Ask before the session is killed, not while it is being rebuilt.
+	if err := trustRepoCommands(proj); err != nil {
+		return err
+	}
+	client := clientFor(proj)
+	if !client.SessionExists(proj.Name) {
+		return CreateSession(proj)
+	}
+	if err := client.RestartSession(proj.Name, func() error { return CreateSession(proj) }); err != nil {
+		return fmt.Errorf(ErrRestartingSession, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+func TestRestartProject(t *testing.T) {
+	c := &config.Config{
+		Projects: []config.Project{{
+			Name: "api", Path: "/src/api", Layout: "dev",
+			OnStart: []config.OnStart{{Window: "server", Command: "go run ."}},
+		}},
+		Layouts: map[string]config.Layout{
+			"dev": {Windows: []config.Window{{Name: "editor"}, {Name: "server"}}},
+		},
+	}
+	t.Setenv(tmux.EnvTmux, "/tmp/tmux-1000/default,1,0")
+	fake := useFake(t, c)
+	s := fake.AddSession("api", "/src/api", "editor", "server", "scratch")
+	s.Windows[0].Active, s.Windows[1].Active = false, true
+	fake.AddSession("web", "/src/web")
+	fake.Client = "api"
+
+	if err := RestartProject(c.FindProject("api")); err != nil {
+		t.Fatalf("RestartProject() error = %v", err)
+	}
+
+	fresh := fake.Session("api")
+	if fresh == s {
+		t.Fatal("session api was not recreated")
+	}
+	if fresh.Window("scratch") != nil {
+		t.Error("window scratch survived the restart")
+	}
+	server := fresh.Window("server")
+	if server == nil || !server.Active {
+		t.Fatalf("server window not focused after restart: %+v", fresh.Windows)
+	}
+	if keys := server.Panes[0].Keys; len(keys) != 1 || keys[0] != "go run . Enter" {
+		t.Errorf("server keys = %q, want go run .", keys)
+	}
+	if fake.Client != "api" {
+		t.Errorf("client on %q, want back on api", fake.Client)
+	}
+}
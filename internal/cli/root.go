@@ -3,16 +3,21 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
 )
 
 // Package-level state for Cobra command closures. This is acceptable for a CLI
 // tool where commands run sequentially, but would be problematic in a library.
 var (
-	cfgPath string
-	cfg     *config.Config
+	cfgPath    string
+	socketName string
+	socketPath string
+	cfg        *config.Config
+	client     = tmux.NewClient(tmux.ExecRunner{})
 )
 
 var rootCmd = &cobra.Command{
@@ -20,8 +25,8 @@ var rootCmd = &cobra.Command{
 	Short: RootShort,
 	Long:  RootLong,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Skip config loading for init command.
-		if cmd.Name() == CmdInit {
+		// Skip config loading for commands that handle the file themselves.
+		if cmd.Name() == CmdInit || cmd.Name() == CmdValidate {
 			return nil
 		}
 
@@ -30,12 +35,52 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf(ErrLoadingConfig, err)
 		}
+		// The picker shows them itself; stderr is gone once it takes the screen.
+		if cmd.Name() != CmdPicker {
+			for _, w := range cfg.Warnings() {
+				fmt.Fprintf(os.Stderr, OutputWarning, w)
+			}
+		}
+
+		// A server chosen on the command line wins over tmux_socket in the config.
+		socket, err := socketFlag()
+		if err != nil {
+			return err
+		}
+		if socket != "" {
+			cfg.OverrideSocket(socket)
+		}
+		client = tmux.NewClient(tmux.SocketRunner(cfg.TmuxSocket))
 		return nil
 	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgPath, FlagConfig, config.DefaultConfigPath(), FlagConfigDesc)
+	rootCmd.PersistentFlags().StringVarP(&socketName, FlagSocketName, FlagSocketNameShort, "", FlagSocketNameDesc)
+	rootCmd.PersistentFlags().StringVarP(&socketPath, FlagSocketPath, FlagSocketPathShort, "", FlagSocketPathDesc)
+	rootCmd.MarkFlagsMutuallyExclusive(FlagSocketName, FlagSocketPath)
+}
+
+// socketFlag returns the tmux server selected with --socket-name or
+// --socket-path, in the form accepted by tmux.SocketRunner.
+func socketFlag() (string, error) {
+	if socketPath == "" {
+		return socketName, nil
+	}
+	abs, err := filepath.Abs(socketPath)
+	if err != nil {
+		return "", fmt.Errorf(ErrSocketPath, err)
+	}
+	return abs, nil
+}
+
+// clientFor returns a client for the tmux server the project's session lives on.
+func clientFor(proj *config.Project) *tmux.Client {
+	if socket := cfg.Socket(proj); socket != cfg.TmuxSocket {
+		return tmux.NewClient(tmux.SocketRunner(socket))
+	}
+	return client
 }
 
 // Execute runs the root Cobra command. It exits with code 1 on error.
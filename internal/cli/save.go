@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+var (
+	saveLayout  string
+	saveProject string
+	saveWrite   bool
+)
+
+var saveCmd = &cobra.Command{
+	Use:          SaveUse,
+	Short:        SaveShort,
+	Long:         SaveLong,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		session := args[0]
+
+		var base []byte
+		if saveWrite {
+			data, err := os.ReadFile(cfgPath)
+			if err != nil {
+				return fmt.Errorf(ErrReadingConfig, err)
+			}
+			base = data
+		}
+
+		out, err := SnapshotConfig(base, session, saveLayout, saveProject)
+		if err != nil {
+			return err
+		}
+
+		if !saveWrite {
+			fmt.Print(string(out))
+			return nil
+		}
+		if err := os.WriteFile(cfgPath, out, filePermissions); err != nil {
+			return fmt.Errorf(ErrWritingConfig, err)
+		}
+		fmt.Printf(OutputSavedLayout, layoutName(session, saveLayout), cfgPath)
+		return nil
+	},
+}
+
+func init() {
+	saveCmd.Flags().StringVar(&saveLayout, FlagAsLayout, "", FlagAsLayoutDesc)
+	saveCmd.Flags().StringVar(&saveProject, FlagProject, "", FlagProjectDesc)
+	saveCmd.Flags().BoolVar(&saveWrite, FlagWrite, false, FlagWriteDesc)
+	rootCmd.AddCommand(saveCmd)
+}
+
+// SnapshotConfig captures the running session as a layout named layout, or
+// after the session when layout is empty, and merges it into the config YAML
+// in base. When project is set, a project of that name using the layout is
+// added too, with the session's directory as its path.
+func SnapshotConfig(base []byte, session, layout, project string) ([]byte, error) {
+	if !client.SessionExists(session) {
+		return nil, fmt.Errorf(ErrSessionNotFound, session)
+	}
+	snap, err := client.SnapshotSession(session)
+	if err != nil {
+		return nil, fmt.Errorf(ErrSnapshotSession, err)
+	}
+
+	var proj *config.Project
+	if project != "" {
+		proj = &config.Project{Name: project, Path: snap.Path}
+	}
+	return config.MergeLayout(base, layoutName(session, layout), snap.Layout, proj)
+}
+
+// layoutName returns the name a snapshot is saved under.
+func layoutName(session, layout string) string {
+	if layout != "" {
+		return layout
+	}
+	return session
+}
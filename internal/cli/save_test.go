@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+func TestSnapshotConfig(t *testing.T) {
+	fake := useFake(t, &config.Config{})
+	s := fake.AddSession("scratch", "/src/scratch", "editor", "logs")
+	s.Windows[0].Panes[0].Command = "nvim"
+
+	out, err := SnapshotConfig(nil, "scratch", "", "notes")
+	if err != nil {
+		t.Fatalf("SnapshotConfig() error = %v", err)
+	}
+	want := `projects:
+  - name: notes
+    path: /src/scratch
+    layout: scratch
+layouts:
+  scratch:
+    windows:
+      - name: editor
+        panes:
+          - command: nvim
+      - name: logs
+`
+	if string(out) != want {
+		t.Errorf("SnapshotConfig() =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := SnapshotConfig(nil, "missing", "", ""); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("SnapshotConfig(missing) error = %v, want session not found", err)
+	}
+}
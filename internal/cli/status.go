@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   StatusUse,
+	Short: StatusShort,
+	Long:  StatusLong,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for i := range cfg.Projects {
+			proj := &cfg.Projects[i]
+			session, running, err := projectSession(proj)
+			if err != nil {
+				return err
+			}
+
+			attached, state, summary := OutputNotAttached, OutputNotRunning, ""
+			if running {
+				if session.Attached {
+					attached = OutputAttached
+				}
+				drift, err := clientFor(proj).DiffSession(proj.Name, cfg.GetLayout(proj))
+				if err != nil {
+					return fmt.Errorf(ErrDiffingSession, proj.Name, err)
+				}
+				state, summary = OutputRunning, driftSummary(drift)
+			}
+			fmt.Printf(FmtStatusLine, attached, proj.Name, state, summary)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+// projectSession returns the project's session on the server it lives on,
+// and whether it is running.
+func projectSession(proj *config.Project) (tmux.SessionInfo, bool, error) {
+	sessions, err := clientFor(proj).ListSessions()
+	if err != nil {
+		return tmux.SessionInfo{}, false, err
+	}
+	for _, s := range sessions {
+		if s.Name == proj.Name {
+			return s, true, nil
+		}
+	}
+	return tmux.SessionInfo{}, false, nil
+}
+
+// driftSummary counts the ways a session differs from its layout, as in
+// "1 missing, 2 extra".
+func driftSummary(d tmux.Drift) string {
+	if d.InSync() {
+		return OutputInSync
+	}
+	var missing, extra, renamed, panes int
+	for _, w := range d.Windows {
+		switch {
+		case w.Missing():
+			missing++
+		case w.Extra():
+			extra++
+		}
+		if w.Renamed() {
+			renamed++
+		}
+		if w.PanesDiffer() {
+			panes++
+		}
+	}
+
+	var parts []string
+	for _, c := range []struct {
+		n      int
+		format string
+	}{
+		{missing, FmtDriftMissing},
+		{extra, FmtDriftExtra},
+		{renamed, FmtDriftRenamed},
+		{panes, FmtDriftPanes},
+	} {
+		if c.n > 0 {
+			parts = append(parts, fmt.Sprintf(c.format, c.n))
+		}
+	}
+	return strings.Join(parts, OutputListSep)
+}
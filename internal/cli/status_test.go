@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+func TestDriftSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []tmux.WindowDiff
+		want    string
+	}{
+		{name: "in sync", windows: []tmux.WindowDiff{{Name: "a", LiveName: "a", WantPanes: 1, GotPanes: 1}}, want: "in sync"},
+		{name: "every kind of drift", windows: []tmux.WindowDiff{
+			{Name: "a", LiveName: "b", WantPanes: 2, GotPanes: 1},
+			{Name: "c", WantPanes: 1},
+			{LiveName: "d", GotPanes: 1},
+			{LiveName: "e", GotPanes: 3},
+		}, want: "1 missing, 2 extra, 1 renamed, 1 with other pane counts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := driftSummary(tmux.Drift{Windows: tt.windows}); got != tt.want {
+				t.Errorf("driftSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
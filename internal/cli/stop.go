@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+var stopCmd = &cobra.Command{
+	Use:          StopUse,
+	Short:        StopShort,
+	Long:         StopLong,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		proj := cfg.FindProject(name)
+		if proj == nil {
+			return fmt.Errorf(ErrProjectNotFound, name)
+		}
+		if !clientFor(proj).SessionExists(proj.Name) {
+			return fmt.Errorf(ErrSessionNotRunning, proj.Name)
+		}
+
+		if err := StopProject(proj); err != nil {
+			return err
+		}
+		fmt.Printf(OutputStopped, proj.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stopCmd)
+}
+
+// StopProject runs the project's on_stop steps and then kills its session.
+// A failing step leaves the session running. When the client is in the
+// session, it is switched to a neighbor first so it stays attached.
+func StopProject(proj *config.Project) error {
+	if err := trustRepoCommands(proj); err != nil {
+		return err
+	}
+	client := clientFor(proj)
+	if err := client.RunOnStop(proj.Name, proj.Path, proj.OnStop); err != nil {
+		return err
+	}
+
+	if tmux.InsideTmux() {
+		if current, _ := client.CurrentSession(); current == proj.Name {
+			if neighbor, ok := client.NeighborSession(proj.Name); ok {
+				// Safe to ignore: without a neighbor to show, the client detaches.
+				_ = client.SwitchClient(neighbor)
+			}
+		}
+	}
+	if err := client.KillSession(proj.Name); err != nil {
+		return fmt.Errorf(ErrKillingSession, err)
+	}
+	return nil
+}
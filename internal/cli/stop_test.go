@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+func TestStopProject(t *testing.T) {
+	c := &config.Config{
+		Projects: []config.Project{{
+			Name: "api", Path: t.TempDir(),
+			OnStop: []config.OnStop{{Window: "main", Keys: "C-c"}, {Window: "main", Wait: "1s"}},
+		}},
+	}
+	t.Setenv(tmux.EnvTmux, "/tmp/tmux-1000/default,1,0")
+	fake := useFake(t, c)
+	s := fake.AddSession("api", "/src/api", "main")
+	fake.AddSession("web", "/src/web")
+	fake.Client = "api"
+
+	if err := StopProject(c.FindProject("api")); err != nil {
+		t.Fatalf("StopProject() error = %v", err)
+	}
+	if keys := s.Window("main").Panes[0].Keys; len(keys) != 1 || keys[0] != "C-c" {
+		t.Errorf("main keys = %q, want C-c", keys)
+	}
+	if fake.Session("api") != nil {
+		t.Error("session api still running")
+	}
+	if fake.Client != "web" {
+		t.Errorf("client on %q, want moved to web", fake.Client)
+	}
+}
+
+func TestStopProjectKeepsSessionWhenStepFails(t *testing.T) {
+	c := &config.Config{
+		Projects: []config.Project{{
+			Name: "api", Path: t.TempDir(),
+			OnStop: []config.OnStop{{Run: "false"}},
+		}},
+	}
+	fake := useFake(t, c)
+	fake.AddSession("api", "/src/api", "main")
+
+	if err := StopProject(c.FindProject("api")); err == nil {
+		t.Fatal("StopProject() error = nil, want the failing step")
+	}
+	if fake.Session("api") == nil {
+		t.Error("session api killed despite the failing step")
+	}
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+// trustInput is where the trust prompt reads its answer from.
+var trustInput io.Reader = os.Stdin
+
+var trustCmd = &cobra.Command{
+	Use:          TrustUse,
+	Short:        TrustShort,
+	Long:         TrustLong,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proj, err := projectOrDir(args)
+		if err != nil {
+			return err
+		}
+
+		file, commands := proj.RepoCommands()
+		if file == "" {
+			fmt.Printf(OutputNoRepoCommands, proj.Name, config.RepoFileName)
+			return nil
+		}
+		printRepoCommands(os.Stdout, file, commands)
+		if err := config.TrustProject(proj); err != nil {
+			return err
+		}
+		fmt.Printf(OutputTrusted, file)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+}
+
+// trustRepoCommands makes sure the user trusts the commands the project runs
+// from its .tplm.yaml, asking when the file is new or has changed since.
+func trustRepoCommands(proj *config.Project) error {
+	err := config.CheckTrusted(proj)
+	if !errors.Is(err, config.ErrUntrusted) {
+		return err
+	}
+	file, commands := proj.RepoCommands()
+	printRepoCommands(os.Stderr, file, commands)
+	if !confirm(trustInput, fmt.Sprintf(PromptTrust, file)) {
+		return err
+	}
+	return config.TrustProject(proj)
+}
+
+// printRepoCommands lists what a .tplm.yaml runs on w. Before a prompt, w is
+// stderr, next to the question.
+func printRepoCommands(w io.Writer, file string, commands []string) {
+	fmt.Fprintf(w, OutputRepoCommands, file)
+	for _, c := range commands {
+		fmt.Fprintf(w, FmtRepoCommand, c)
+	}
+}
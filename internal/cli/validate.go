@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+var validateCmd = &cobra.Command{
+	Use:          ValidateUse,
+	Short:        ValidateShort,
+	Long:         ValidateLong,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issues, err := config.Validate(cfgPath)
+		if err != nil {
+			return fmt.Errorf(ErrValidatingConfig, err)
+		}
+
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf(ErrInvalidConfig, len(issues))
+		}
+
+		fmt.Printf(OutputConfigValid, cfgPath)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
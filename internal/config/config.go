@@ -6,8 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -29,28 +27,75 @@ func DefaultConfigPath() string {
 	return filepath.Join(home, ConfigDir, ConfigApp, ConfigFile)
 }
 
-// Load reads and parses the YAML config file at the given path.
+// Load reads and parses the YAML config file at the given path, merged with
+// the files it includes and those in the conf.d directory next to it.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	cfg, _, issues, err := loadFiles(path)
 	if err != nil {
-		return nil, fmt.Errorf(ErrReadingConfig, err)
+		return nil, err
 	}
-
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf(ErrParsingConfig, err)
+	if len(issues) > 0 {
+		return nil, fmt.Errorf(ErrFmtMergingConfig, issues[0])
+	}
+	if err := cfg.resolveLayouts(); err != nil {
+		return nil, fmt.Errorf(ErrResolvingLayouts, err)
 	}
 
 	// Resolve ~ in project paths.
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return &cfg, nil
+	if home, err := os.UserHomeDir(); err == nil {
+		cfg.TmuxSocket = expandHome(cfg.TmuxSocket, home)
+		for i := range cfg.Projects {
+			cfg.Projects[i].Path = expandHome(cfg.Projects[i].Path, home)
+			cfg.Projects[i].TmuxSocket = expandHome(cfg.Projects[i].TmuxSocket, home)
+		}
+		if cfg.Discover != nil {
+			for i := range cfg.Discover.Roots {
+				cfg.Discover.Roots[i] = expandHome(cfg.Discover.Roots[i], home)
+			}
+		}
 	}
-	for i := range cfg.Projects {
-		cfg.Projects[i].Path = expandHome(cfg.Projects[i].Path, home)
+	cfg.addDiscovered(false)
+
+	if err := cfg.prepareProjects(0); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
+
+// prepareProjects prepares the projects from index from on. A discovered
+// project that fails is dropped with a warning rather than failing the config,
+// since one broken repo under a discover root shouldn't break every command.
+func (c *Config) prepareProjects(from int) error {
+	kept := c.Projects[:from]
+	for _, proj := range c.Projects[from:] {
+		if err := c.prepareProject(&proj); err != nil {
+			if !proj.discovered {
+				return err
+			}
+			c.warnings = append(c.warnings, fmt.Errorf(ErrFmtSkippedProject, proj.Name, err))
+			continue
+		}
+		kept = append(kept, proj)
+	}
+	c.Projects = kept
+	return nil
+}
 
-	return &cfg, nil
+// Warnings returns the problems Load skipped over instead of failing.
+func (c *Config) Warnings() []error {
+	return c.warnings
+}
+
+// prepareProject completes the project from its .tplm.yaml and expands its
+// templates.
+func (c *Config) prepareProject(proj *Project) error {
+	if _, err := c.applyRepoFile(proj); err != nil {
+		return err
+	}
+	if err := c.expandProject(proj); err != nil {
+		return inFile(c.sources.projects[proj.Name], err)
+	}
+	return nil
 }
 
 // FindProject returns the project with the given name, or nil.
@@ -63,8 +108,12 @@ func (c *Config) FindProject(name string) *Project {
 	return nil
 }
 
-// GetLayout returns the layout for a project, falling back to a single-window default.
+// GetLayout returns the layout for a project, falling back to a single-window
+// default. Once Load expanded the project's templates, it is the expanded layout.
 func (c *Config) GetLayout(proj *Project) Layout {
+	if proj.layout != nil {
+		return *proj.layout
+	}
 	if proj.Layout != "" {
 		if l, ok := c.Layouts[proj.Layout]; ok {
 			return l
@@ -76,6 +125,24 @@ func (c *Config) GetLayout(proj *Project) Layout {
 	}
 }
 
+// Socket returns the tmux socket a project's session lives on: the project's
+// own tmux_socket, else the global one. An empty result means the default server.
+func (c *Config) Socket(proj *Project) string {
+	if proj.TmuxSocket != "" {
+		return proj.TmuxSocket
+	}
+	return c.TmuxSocket
+}
+
+// OverrideSocket makes every project use socket, discarding tmux_socket
+// settings from the file. It is used when the server is chosen on the command line.
+func (c *Config) OverrideSocket(socket string) {
+	c.TmuxSocket = socket
+	for i := range c.Projects {
+		c.Projects[i].TmuxSocket = ""
+	}
+}
+
 func expandHome(path, home string) string {
 	if strings.HasPrefix(path, homePrefix) {
 		return filepath.Join(home, path[2:])
@@ -88,15 +155,28 @@ func ExampleConfig() string {
 	return `# tplm configuration
 # Place this file at ~/.config/tplm/config.yaml
 
+# Uncomment to use a separate tmux server (a socket name, or a path like ~/.tmux-work.sock).
+# tmux_socket: work
+
 projects:
   - name: my-api
     path: ~/Projects/my-api
     layout: dev
+    # Run in the project path before the session is created; a failure aborts it.
+    # before_start:
+    #   - docker compose up -d
     on_start:
       - window: editor
         command: nvim .
       - window: server
         command: "echo 'start your server here'"
+    # Run by tplm stop and the picker's kill before the session is killed.
+    # on_stop:
+    #   - window: server
+    #     keys: C-c
+    #   - window: server
+    #     wait: 10s
+    #   - run: docker compose down
 
   - name: frontend
     path: ~/Projects/frontend
@@ -199,3 +199,27 @@ func TestDefaultConfigPath(t *testing.T) {
 		t.Errorf("DefaultConfigPath() base = %q, want %q", filepath.Base(path), ConfigFile)
 	}
 }
+
+func TestSocket(t *testing.T) {
+	cfg := &Config{
+		TmuxSocket: "work",
+		Projects: []Project{
+			{Name: "global"},
+			{Name: "own", TmuxSocket: "/tmp/personal.sock"},
+		},
+	}
+
+	if got := cfg.Socket(cfg.FindProject("global")); got != "work" {
+		t.Errorf("Socket(global) = %q, want %q", got, "work")
+	}
+	if got := cfg.Socket(cfg.FindProject("own")); got != "/tmp/personal.sock" {
+		t.Errorf("Socket(own) = %q, want %q", got, "/tmp/personal.sock")
+	}
+
+	cfg.OverrideSocket("cli")
+	for _, name := range []string{"global", "own"} {
+		if got := cfg.Socket(cfg.FindProject(name)); got != "cli" {
+			t.Errorf("after OverrideSocket, Socket(%s) = %q, want %q", name, got, "cli")
+		}
+	}
+}
@@ -1,14 +1,181 @@
 package config
 
+import "time"
+
 // Config file path components.
 const (
 	ConfigDir  = ".config"
 	ConfigApp  = "tplm"
 	ConfigFile = "config.yaml"
+	ConfDir    = "conf.d" // next to the config file; its *.yaml files are included
+	ConfDGlob  = "*.yaml"
+	TrustFile  = "trusted" // next to the default config file
+)
+
+// DiscoverCacheFile holds the last discovery scan, in the tplm directory of
+// the user's cache directory.
+const DiscoverCacheFile = "discover.json"
+
+// Discovery defaults.
+const (
+	DefaultDiscoverDepth  = 2
+	DefaultDiscoverMarker = ".git"
+	DefaultDiscoverTTL    = time.Hour
+)
+
+// hiddenPrefix starts the names of directories discovery skips.
+const hiddenPrefix = "."
+
+// RepoFileName is the file in a project's directory that can define its
+// layout and commands.
+const RepoFileName = ".tplm.yaml"
+
+// Trust file syntax: one "checksum path" line per trusted file.
+const (
+	trustSep     = " "
+	trustLineFmt = "%s" + trustSep + "%s\n"
 )
 
+// Descriptions of the commands, vars and env a .tplm.yaml sets, shown before
+// the user trusts it.
+const (
+	FmtRepoPane        = "window %s: %s"
+	FmtRepoBeforeStart = "before_start: %s"
+	FmtRepoOnStart     = "on_start in %s: %s"
+	FmtRepoOnStopRun   = "on_stop: %s"
+	FmtRepoOnStopKeys  = "on_stop keys in %s: %s"
+	FmtRepoVar         = "vars: %s=%s"
+	FmtRepoEnv         = "env: %s=%s"
+	FmtRepoEnvFile     = "env_file: %s"
+	FmtRepoWindowEnv   = "window %s env: %s=%s"
+	FmtRepoPaneEnv     = "window %s pane %d env: %s=%s"
+)
+
+// globMeta holds the characters that make an include a glob rather than a path.
+const globMeta = "*?["
+
 // Error message templates.
 const (
-	ErrReadingConfig = "reading config: %w"
-	ErrParsingConfig = "parsing config: %w"
+	ErrReadingConfig     = "reading config: %w"
+	ErrParsingConfig     = "parsing config: %w"
+	ErrFmtNotMapping     = "config is not a mapping; can't add %s"
+	ErrFmtEnvFile        = "env_file %s: %w"
+	ErrFmtDotenvLine     = "line %d: want NAME=value"
+	ErrFmtDotenvValue    = "line %d: %w"
+	ErrUnterminatedQuote = "unterminated quote"
+	ErrResolvingLayouts  = "resolving layouts: %w"
+	ErrFmtLayoutCycle    = "layout %q: extends cycle %s"
+	ErrFmtUnknownBase    = "layout %q extends unknown layout %q"
+	ErrFmtUnknownWindow  = "layout %q uses unknown window %q"
+	ErrFmtNestedUse      = "window %q uses window %q, but window definitions can't use others"
+	ErrFmtProjectTmpl    = "project %q: %w"
+	ErrFmtTemplate       = "expanding %q: %w"
+	ErrFmtParsingFile    = "parsing %s: %w"
+	ErrFmtInclude        = "include %s: %w"
+	ErrFmtMergingConfig  = "merging config: %v"
+	ErrFmtInFile         = "%s: %w"
+	ErrFmtNoRepoFile     = "no project has path %s and it has no %s"
+	ErrFmtUntrusted      = "%s: %w; review them with tplm trust"
+	ErrFmtTrustFile      = "trust file: %w"
+	ErrFmtSkippedProject = "skipping discovered project %q: %w"
+)
+
+// Kinds of definitions merged across config files, as named in issues.
+const (
+	kindProject = "project"
+	kindLayout  = "layout"
+	kindWindow  = "window"
+)
+
+// Template syntax. A missing variable fails the expansion rather than
+// expanding to nothing.
+const (
+	templateOpen       = "{{"
+	templateMissingKey = "missingkey=error"
+)
+
+// cycleSep joins the layouts of an extends cycle in error messages.
+const cycleSep = " -> "
+
+// Dotenv syntax.
+const (
+	envSep              = "="
+	dotenvComment       = "#"
+	dotenvInlineComment = " #"
+	dotenvExport        = "export "
+)
+
+// Split direction values accepted in pane definitions.
+const (
+	SplitHorizontal = "horizontal"
+	SplitVertical   = "vertical"
+)
+
+// Built-in tmux layouts a window's layout can name.
+const (
+	LayoutEvenHorizontal = "even-horizontal"
+	LayoutEvenVertical   = "even-vertical"
+	LayoutMainHorizontal = "main-horizontal"
+	LayoutMainVertical   = "main-vertical"
+	LayoutTiled          = "tiled"
+)
+
+// DefaultWaitTimeout bounds an on_start wait_for that sets no timeout.
+const DefaultWaitTimeout = 30 * time.Second
+
+// Pane size limits, in percent.
+const (
+	MinSizePercent = 1
+	MaxSizePercent = 100
+)
+
+// Validation issue templates.
+const (
+	IssueFmtPosition        = "%s:%d:%d: %s"
+	IssueDuplicateProject   = "duplicate project name %q (first defined at line %d)"
+	IssueEmptyProjectName   = "project has an empty name"
+	IssueUnknownLayout      = "project %q: unknown layout %q"
+	IssueMissingPath        = "project %q: path is empty"
+	IssuePathNotFound       = "project %q: path %q does not exist"
+	IssuePathNotDir         = "project %q: path %q is not a directory"
+	IssueOnStartNoWindow    = "project %q: on_start window %q is not in layout %q"
+	IssueWaitForCondition   = "project %q: on_start wait_for in window %q must set exactly one of port, file, output or delay"
+	IssueWaitForDuration    = "project %q: on_start wait_for %s %q is not a positive duration like \"10s\""
+	IssueWaitForOutput      = "project %q: on_start wait_for output %q is not a valid regular expression: %v"
+	IssueWaitForPort        = "project %q: on_start wait_for port %q is not a port or host:port"
+	IssueOnStopNoWindow     = "project %q: on_stop window %q is not in layout %q"
+	IssueOnStopAction       = "project %q: on_stop step %d must set exactly one of keys, wait or run"
+	IssueOnStopNeedsWindow  = "project %q: on_stop step %d sends keys or waits but names no window"
+	IssueOnStopWait         = "project %q: on_stop wait %q is not a positive duration like \"10s\""
+	IssueRootNotFound       = "project %q: root %q of window %q is not a directory"
+	IssueEnvName            = "%s: invalid environment variable name %q"
+	IssueEnvFile            = "project %q: env_file %q: %v"
+	IssueTemplate           = "%v"
+	IssueRepoFile           = "project %q: %v"
+	IssueFmtProject         = "project %q"
+	IssueFmtLayout          = "layout %q"
+	IssueFmtWindow          = "layout %q, window %q"
+	IssueFmtPane            = "layout %q, window %q, pane %d"
+	IssueEmptyWindowName    = "layout %q: window %d has an empty name"
+	IssueInvalidSplit       = "layout %q, window %q: invalid split %q (want %q or %q)"
+	IssueMalformedSize      = "layout %q, window %q: malformed size %q (want a percentage like \"30%%\")"
+	IssueSizeOutOfRange     = "layout %q, window %q: size %q is outside %d%%-%d%%"
+	IssueTreeAndPanes       = "layout %q, window %q: set panes or tree, not both"
+	IssueTreeLeafSplit      = "layout %q, window %q: tree node splits %q but has no children"
+	IssueTreeNodePane       = "layout %q, window %q: tree node with children can't set command, root or env"
+	IssueTreeSizes          = "layout %q, window %q: tree children sizes add up to %d%%, more than %d%%"
+	IssueWindowLayout       = "layout %q, window %q: layout %q is neither a tmux layout name nor a valid layout string"
+	IssueLayoutPanes        = "layout %q, window %q: layout string has %d panes, window has %d"
+	IssueReapplyNoLayout    = "layout %q, window %q: reapply_layout is set but layout is empty"
+	IssueUnknownBase        = "layout %q extends unknown layout %q"
+	IssueLayoutCycle        = "layout %q: extends cycle"
+	IssueUnknownWindow      = "layout %q uses unknown window %q"
+	IssueNestedUse          = "window %q: window definitions can't use others"
+	IssueMainOnly           = "%s can only be set in the main config"
+	IssueConflict           = "%s %q conflicts with the one in %s"
+	IssueDiscoverRoot       = "discover: root %q is not a directory"
+	IssueDiscoverDepth      = "discover: max_depth %d is negative"
+	IssueDiscoverLayout     = "discover: unknown layout %q"
+	IssueDiscoverTTL        = "discover: cache_ttl %q is not a duration like \"1h\""
+	defaultLayoutIssueLabel = "(default)"
 )
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Discover finds projects in directories under root directories: each one
+// holding a marker file becomes a project named after the directory.
+type Discover struct {
+	Roots         []string          `yaml:"roots"`
+	MaxDepth      int               `yaml:"max_depth,omitempty"`      // levels below a root to look in; default 2
+	Markers       []string          `yaml:"markers,omitempty"`        // files or directories that make a project; default .git
+	Layout        string            `yaml:"layout,omitempty"`         // layout of discovered projects
+	MarkerLayouts map[string]string `yaml:"marker_layouts,omitempty"` // layout by marker, e.g. go.mod: go-service
+	CacheTTL      string            `yaml:"cache_ttl,omitempty"`      // how long a scan is reused; default 1h, 0 disables
+}
+
+// discoverCache is the discovery cache file: the projects a scan with the
+// given settings found, and when.
+type discoverCache struct {
+	Settings Discover
+	Scanned  time.Time
+	Projects []discoveredProject
+}
+
+type discoveredProject struct {
+	Name   string
+	Path   string
+	Layout string
+}
+
+// DiscoverCachePath returns the file discovery results are cached in, in the
+// user's cache directory.
+func DiscoverCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = fallbackDir
+	}
+	return filepath.Join(dir, ConfigApp, DiscoverCacheFile)
+}
+
+// addDiscovered adds the projects discovery finds to c, after the explicit
+// ones. An explicit project wins over a discovered one with its name or path.
+// The roots are scanned again only when refresh is set or the cached scan is
+// stale.
+func (c *Config) addDiscovered(refresh bool) {
+	if c.Discover == nil || len(c.Discover.Roots) == 0 {
+		return
+	}
+	found, ok := c.Discover.cached()
+	if refresh || !ok {
+		found = c.Discover.scan()
+		c.Discover.writeCache(found)
+	}
+
+	for _, d := range found {
+		taken := slices.ContainsFunc(c.Projects, func(p Project) bool {
+			return p.Name == d.Name || filepath.Clean(p.Path) == d.Path
+		})
+		if !taken {
+			c.Projects = append(c.Projects, Project{Name: d.Name, Path: d.Path, Layout: d.Layout, discovered: true})
+		}
+	}
+}
+
+// RefreshDiscovered scans the discover roots again, replacing the discovered
+// projects of c and the cache. Projects that fail to load are skipped with a
+// warning, as in Load.
+func (c *Config) RefreshDiscovered() error {
+	c.Projects = slices.DeleteFunc(c.Projects, func(p Project) bool { return p.discovered })
+	c.warnings = nil // all of them are about discovered projects
+	n := len(c.Projects)
+	c.addDiscovered(true)
+	return c.prepareProjects(n)
+}
+
+// cached returns the projects of the cached scan, if it was made with the
+// same settings and is fresh.
+func (d *Discover) cached() ([]discoveredProject, bool) {
+	ttl := DefaultDiscoverTTL
+	if d.CacheTTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(d.CacheTTL); err != nil {
+			return nil, false
+		}
+	}
+	data, err := os.ReadFile(DiscoverCachePath())
+	if err != nil {
+		return nil, false
+	}
+	var cache discoverCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, false
+	}
+	if !reflect.DeepEqual(cache.Settings, *d) || time.Since(cache.Scanned) > ttl {
+		return nil, false
+	}
+	return cache.Projects, true
+}
+
+func (d *Discover) writeCache(found []discoveredProject) {
+	data, err := json.Marshal(discoverCache{Settings: *d, Scanned: time.Now(), Projects: found})
+	if err != nil {
+		return
+	}
+	// The cache only saves a scan; failing to write it costs the next one.
+	path := DiscoverCachePath()
+	if os.MkdirAll(filepath.Dir(path), 0755) == nil {
+		_ = os.WriteFile(path, data, 0644)
+	}
+}
+
+// scan walks the roots, in order, for directories holding a marker. Hidden
+// directories are skipped, and so are those inside a project. When two
+// directories have the same name, the first one found is the project.
+func (d *Discover) scan() []discoveredProject {
+	depth := d.MaxDepth
+	if depth == 0 {
+		depth = DefaultDiscoverDepth
+	}
+	markers := d.Markers
+	if len(markers) == 0 {
+		markers = []string{DefaultDiscoverMarker}
+	}
+
+	var found []discoveredProject
+	seen := make(map[string]bool)
+	var walk func(dir string, level int)
+	walk = func(dir string, level int) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), hiddenPrefix) {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if layout, ok := d.project(path, markers); ok {
+				name := sessionNameReplacer.Replace(e.Name())
+				if !seen[name] {
+					seen[name] = true
+					found = append(found, discoveredProject{Name: name, Path: path, Layout: layout})
+				}
+				continue
+			}
+			if level < depth {
+				walk(path, level+1)
+			}
+		}
+	}
+	for _, root := range d.Roots {
+		walk(filepath.Clean(root), 1)
+	}
+	return found
+}
+
+// project reports whether dir holds one of the markers, and returns the
+// layout of the first one that has a layout of its own, or else the default.
+func (d *Discover) project(dir string, markers []string) (string, bool) {
+	layout, ok := "", false
+	for _, m := range markers {
+		if _, err := os.Stat(filepath.Join(dir, m)); err != nil {
+			continue
+		}
+		if !ok {
+			layout, ok = d.Layout, true
+		}
+		if l, has := d.MarkerLayouts[m]; has {
+			return l, true
+		}
+	}
+	return layout, ok
+}
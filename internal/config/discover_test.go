@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadDiscoversProjects(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	code := writeFiles(t, map[string]string{
+		"api/.git/HEAD":              "",
+		"api/go.mod":                 "",
+		"api/vendor/lib/.git/HEAD":   "", // inside a project
+		"web/package.json":           "",
+		"org/billing/.git/HEAD":      "",
+		"org/deep/er/tool/.git/HEAD": "", // below max_depth
+		".hidden/secret/.git/HEAD":   "",
+		"notes/README":               "",
+		"mine/.git/HEAD":             "", // listed explicitly
+		"other/pinned/.git/HEAD":     "", // listed explicitly under another name
+	})
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+projects:
+  - name: mine
+    path: /src/mine
+  - name: pinned-app
+    path: ` + filepath.Join(code, "other/pinned") + `
+discover:
+  roots: [` + code + `]
+  markers: [.git, go.mod, package.json]
+  layout: dev
+  marker_layouts:
+    go.mod: go
+layouts:
+  dev:
+    windows:
+      - name: editor
+  go:
+    windows:
+      - name: server
+`
+	if err := os.WriteFile(config, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(config)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	type found struct{ Name, Path, Layout string }
+	var got []found
+	for _, p := range cfg.Projects {
+		got = append(got, found{p.Name, p.Path, p.Layout})
+	}
+	want := []found{
+		{"mine", "/src/mine", ""},
+		{"pinned-app", filepath.Join(code, "other/pinned"), ""},
+		{"api", filepath.Join(code, "api"), "go"},
+		{"billing", filepath.Join(code, "org/billing"), "dev"},
+		{"web", filepath.Join(code, "web"), "dev"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("projects = %+v, want %+v", got, want)
+	}
+
+	// A new repo shows up once the cached scan is refreshed.
+	if err := os.MkdirAll(filepath.Join(code, "new/.git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(config)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.FindProject("new") != nil {
+		t.Error("Load() scanned again instead of using the cache")
+	}
+	if err := cfg.RefreshDiscovered(); err != nil {
+		t.Fatalf("RefreshDiscovered() error = %v", err)
+	}
+	if cfg.FindProject("new") == nil || len(cfg.Projects) != len(want)+1 {
+		t.Errorf("projects after refresh = %d, want the new one added", len(cfg.Projects))
+	}
+	if got := cfg.GetLayout(cfg.FindProject("new")).Windows[0].Name; got != "editor" {
+		t.Errorf("new project window = %q, want the dev layout's", got)
+	}
+}
+
+func TestLoadSkipsBrokenDiscoveredProject(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	code := writeFiles(t, map[string]string{
+		"api/.git/HEAD":        "",
+		"junk/.git/HEAD":       "",
+		"junk/" + RepoFileName: "windows: [oops\n",
+		"tmpl/.git/HEAD":       "",
+		"tmpl/" + RepoFileName: "windows:\n  - name: \"{{.Vars.missing}}\"\n",
+	})
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte("discover:\n  roots: ["+code+"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(config)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Projects) != 1 || cfg.Projects[0].Name != "api" {
+		t.Errorf("projects = %+v, want only api", cfg.Projects)
+	}
+	warnings := cfg.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("Warnings() = %v, want one per broken project", warnings)
+	}
+	for i, name := range []string{"junk", "tmpl"} {
+		if !strings.Contains(warnings[i].Error(), `discovered project "`+name+`"`) {
+			t.Errorf("warning %d = %v, want it to name %s", i, warnings[i], name)
+		}
+	}
+
+	if err := cfg.RefreshDiscovered(); err != nil {
+		t.Fatalf("RefreshDiscovered() error = %v", err)
+	}
+	if len(cfg.Projects) != 1 || len(cfg.Warnings()) != 2 {
+		t.Errorf("after refresh: projects = %d, warnings = %v", len(cfg.Projects), cfg.Warnings())
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pairs returns the variables as sorted "NAME=value" strings, the form tmux
+// takes them in.
+func (e Env) Pairs() []string {
+	pairs := make([]string, 0, len(e))
+	for name, value := range e {
+		pairs = append(pairs, name+envSep+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// With returns the variables of e overridden by those of over. Neither is
+// modified.
+func (e Env) With(over Env) Env {
+	if len(over) == 0 {
+		return e
+	}
+	out := make(Env, len(e)+len(over))
+	for name, value := range e {
+		out[name] = value
+	}
+	for name, value := range over {
+		out[name] = value
+	}
+	return out
+}
+
+// SessionEnv returns the environment of the project's session: the layout's
+// env, overridden by the project's env_file files in order, overridden by
+// the project's env.
+func (c *Config) SessionEnv(proj *Project) (Env, error) {
+	env := c.GetLayout(proj).Env
+	for _, file := range proj.EnvFile {
+		vars, err := readEnvFile(proj.Path, file)
+		if err != nil {
+			return nil, fmt.Errorf(ErrFmtEnvFile, file, err)
+		}
+		env = env.With(vars)
+	}
+	return env.With(proj.Env), nil
+}
+
+// readEnvFile parses the dotenv file at path, relative to dir.
+func readEnvFile(dir, path string) (Env, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDotenv(data)
+}
+
+// ParseDotenv parses NAME=value lines as written in .env files. Blank lines
+// and lines starting with # are skipped, and a leading "export " is allowed.
+// Values may be single-quoted (taken literally) or double-quoted (with \n,
+// \t, \" and \\ escapes); unquoted values end at " #".
+func ParseDotenv(data []byte) (Env, error) {
+	env := make(Env)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, dotenvComment) {
+			continue
+		}
+		line = strings.TrimPrefix(line, dotenvExport)
+
+		name, value, ok := strings.Cut(line, envSep)
+		name = strings.TrimSpace(name)
+		if !ok || !ValidEnvName(name) {
+			return nil, fmt.Errorf(ErrFmtDotenvLine, n)
+		}
+		value, err := dotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf(ErrFmtDotenvValue, n, err)
+		}
+		env[name] = value
+	}
+	return env, sc.Err()
+}
+
+func dotenvValue(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, "'"):
+		end := strings.Index(v[1:], "'")
+		if end < 0 {
+			return "", errors.New(ErrUnterminatedQuote)
+		}
+		return v[1 : end+1], nil
+	case strings.HasPrefix(v, `"`):
+		quoted, err := strconv.QuotedPrefix(v)
+		if err != nil {
+			return "", errors.New(ErrUnterminatedQuote)
+		}
+		return strconv.Unquote(quoted)
+	}
+	if i := strings.Index(v, dotenvInlineComment); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v, nil
+}
+
+// ValidEnvName reports whether name can be used as an environment variable:
+// letters, digits and underscores, not starting with a digit.
+func ValidEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	data := `# database
+DB_HOST=localhost
+export DB_PORT = 5432
+EMPTY=
+URL=http://x/#anchor # trailing comment
+SINGLE='keep $HOME \n as is'
+DOUBLE="line one\nline \"two\""
+
+`
+	want := Env{
+		"DB_HOST": "localhost",
+		"DB_PORT": "5432",
+		"EMPTY":   "",
+		"URL":     "http://x/#anchor",
+		"SINGLE":  `keep $HOME \n as is`,
+		"DOUBLE":  "line one\nline \"two\"",
+	}
+	got, err := ParseDotenv([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseDotenv() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDotenv() = %q, want %q", got, want)
+	}
+
+	for data, wantErr := range map[string]string{
+		"A=1\nnot a pair\n": "line 2: want NAME=value",
+		"1A=x":              "line 1: want NAME=value",
+		`A="open`:           "line 1: unterminated quote",
+	} {
+		if _, err := ParseDotenv([]byte(data)); err == nil || err.Error() != wantErr {
+			t.Errorf("ParseDotenv(%q) error = %v, want %q", data, err, wantErr)
+		}
+	}
+}
+
+func TestSessionEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("A=file\nB=file\nC=file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		Projects: []Project{{
+			Name: "api", Path: dir, Layout: "dev",
+			EnvFile: []string{".env"},
+			Env:     Env{"C": "project"},
+		}},
+		Layouts: map[string]Layout{"dev": {Env: Env{"A": "layout", "B": "layout", "L": "layout"}}},
+	}
+
+	got, err := cfg.SessionEnv(&cfg.Projects[0])
+	if err != nil {
+		t.Fatalf("SessionEnv() error = %v", err)
+	}
+	want := Env{"A": "file", "B": "file", "C": "project", "L": "layout"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SessionEnv() = %v, want %v", got, want)
+	}
+	if cfg.Layouts["dev"].Env["A"] != "layout" {
+		t.Error("SessionEnv modified the layout's env")
+	}
+
+	cfg.Projects[0].EnvFile = []string{"missing.env"}
+	if _, err := cfg.SessionEnv(&cfg.Projects[0]); err == nil || !strings.Contains(err.Error(), "env_file missing.env") {
+		t.Errorf("SessionEnv() error = %v, want the missing env_file named", err)
+	}
+}
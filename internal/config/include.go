@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is one file of a config, decoded on its own: the main file or
+// one it includes.
+type configFile struct {
+	path string
+	doc  yaml.Node
+	cfg  Config
+}
+
+// sources records the file each project, layout and window definition was
+// read from, for error messages.
+type sources struct {
+	projects map[string]string
+	layouts  map[string]string
+	windows  map[string]string
+}
+
+// readConfigFile reads and decodes one config file.
+func readConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadingConfig, err)
+	}
+	f := &configFile{path: path}
+	if err := yaml.Unmarshal(data, &f.doc); err != nil {
+		return nil, fmt.Errorf(ErrFmtParsingFile, path, err)
+	}
+	if f.doc.Kind != 0 {
+		if err := f.doc.Decode(&f.cfg); err != nil {
+			return nil, fmt.Errorf(ErrFmtParsingFile, path, err)
+		}
+	}
+	return f, nil
+}
+
+// loadFiles reads the config at path and the files it includes, and merges
+// them into one config. Definitions that can't be merged are returned as
+// issues; the error is non-nil only when a file can't be read or parsed.
+func loadFiles(path string) (*Config, []*configFile, []Issue, error) {
+	main, err := readConfigFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	paths, err := includedFiles(path, main.cfg.Include)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cfg := main.cfg
+	cfg.Projects = slices.Clone(cfg.Projects)
+	cfg.Layouts = maps.Clone(cfg.Layouts)
+	cfg.Windows = maps.Clone(cfg.Windows)
+	cfg.sources = sources{
+		projects: make(map[string]string),
+		layouts:  make(map[string]string),
+		windows:  make(map[string]string),
+	}
+	cfg.noteSources(main)
+
+	files := []*configFile{main}
+	var issues []Issue
+	for _, p := range paths {
+		f, err := readConfigFile(p)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		files = append(files, f)
+		issues = append(issues, cfg.mergeFile(f, path)...)
+	}
+	return &cfg, files, issues, nil
+}
+
+// includedFiles returns the files the main config at path pulls in: the
+// matches of its include globs, in order, then conf.d/*.yaml next to it.
+// Globs are relative to the main config's directory. Each file comes once.
+func includedFiles(path string, include []string) ([]string, error) {
+	dir := filepath.Dir(path)
+	home, _ := os.UserHomeDir()
+
+	patterns := make([]string, 0, len(include)+1)
+	for _, pattern := range include {
+		if home != "" {
+			pattern = expandHome(pattern, home)
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	confD := filepath.Join(dir, ConfDir, ConfDGlob)
+
+	seen := map[string]bool{filepath.Clean(path): true}
+	var files []string
+	for _, pattern := range append(patterns, confD) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf(ErrFmtInclude, pattern, err)
+		}
+		if len(matches) == 0 && pattern != confD && !strings.ContainsAny(pattern, globMeta) {
+			return nil, fmt.Errorf(ErrFmtInclude, pattern, os.ErrNotExist)
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	return files, nil
+}
+
+// noteSources records f as the source of its definitions that have none yet.
+func (c *Config) noteSources(f *configFile) {
+	for _, p := range f.cfg.Projects {
+		if _, ok := c.sources.projects[p.Name]; !ok {
+			c.sources.projects[p.Name] = f.path
+		}
+	}
+	for name := range f.cfg.Layouts {
+		if _, ok := c.sources.layouts[name]; !ok {
+			c.sources.layouts[name] = f.path
+		}
+	}
+	for name := range f.cfg.Windows {
+		if _, ok := c.sources.windows[name]; !ok {
+			c.sources.windows[name] = f.path
+		}
+	}
+}
+
+// mergeFile adds the projects, layouts and window definitions of an included
+// file to c, by name. The main config's definitions win over the file's; one
+// from another included file must be identical to the file's, else the file's
+// is reported and dropped.
+func (c *Config) mergeFile(f *configFile, main string) []Issue {
+	v := validator{file: f.path}
+	root := documentRoot(&f.doc)
+	for _, key := range []string{keyInclude, keyTmuxSocket, keyDiscover} {
+		if mappingValue(root, key) != nil {
+			v.report(mappingKey(root, key), IssueMainOnly, key)
+		}
+	}
+
+	// keep reports whether a definition of the named kind is new to c.
+	keep := func(kind, name string, existing, def any, defined map[string]string, n *yaml.Node) bool {
+		src, dup := defined[name]
+		switch {
+		case !dup:
+			defined[name] = f.path
+			return true
+		case src != main && !reflect.DeepEqual(existing, def):
+			v.report(n, IssueConflict, kind, name, src)
+		}
+		return false
+	}
+
+	projects := mappingValue(root, keyProjects)
+	for i, p := range f.cfg.Projects {
+		existing := c.FindProject(p.Name)
+		var prev Project
+		if existing != nil {
+			prev = *existing
+		}
+		if keep(kindProject, p.Name, prev, p, c.sources.projects, fieldNode(sequenceItem(projects, i), keyName)) {
+			c.Projects = append(c.Projects, p)
+		}
+	}
+	layouts := mappingValue(root, keyLayouts)
+	for name, l := range f.cfg.Layouts {
+		if keep(kindLayout, name, c.Layouts[name], l, c.sources.layouts, mappingKey(layouts, name)) {
+			if c.Layouts == nil {
+				c.Layouts = make(map[string]Layout)
+			}
+			c.Layouts[name] = l
+		}
+	}
+	windows := mappingValue(root, keyWindows)
+	for name, w := range f.cfg.Windows {
+		if keep(kindWindow, name, c.Windows[name], w, c.sources.windows, mappingKey(windows, name)) {
+			if c.Windows == nil {
+				c.Windows = make(map[string]Window)
+			}
+			c.Windows[name] = w
+		}
+	}
+	v.sortIssues()
+	return v.issues
+}
+
+// inFile prefixes err with the file a definition came from, when known.
+func inFile(file string, err error) error {
+	if file == "" {
+		return err
+	}
+	return fmt.Errorf(ErrFmtInFile, file, err)
+}
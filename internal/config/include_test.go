@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files, relative to a new directory, and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": `
+include:
+  - shared/*.yaml
+projects:
+  - name: api
+    path: /src/api
+    layout: service
+layouts:
+  service:
+    windows:
+      - name: mine
+`,
+		"shared/layouts.yaml": `
+layouts:
+  service:
+    windows:
+      - name: theirs
+  web:
+    windows:
+      - use: editor
+windows:
+  editor:
+    panes:
+      - command: nvim .
+`,
+		"shared/projects.yaml": `
+projects:
+  - name: web
+    path: /src/web
+    layout: web
+`,
+		"conf.d/local.yaml": `
+projects:
+  - name: web
+    path: /src/web
+    layout: web
+  - name: scratch
+    path: /tmp
+`,
+		"conf.d/notes.txt": "not yaml",
+	})
+
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var names []string
+	for _, p := range cfg.Projects {
+		names = append(names, p.Name)
+	}
+	if got, want := strings.Join(names, " "), "api web scratch"; got != want {
+		t.Errorf("projects = %s, want %s", got, want)
+	}
+	if got := cfg.GetLayout(cfg.FindProject("api")).Windows[0].Name; got != "mine" {
+		t.Errorf("service window = %q, want the main config's %q", got, "mine")
+	}
+	if got := cfg.GetLayout(cfg.FindProject("web")).Windows[0].Panes[0].Command; got != "nvim ." {
+		t.Errorf("web pane command = %q, want the included window definition's", got)
+	}
+}
+
+func TestLoadIncludeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "conflicting duplicates",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml, b.yaml]\n",
+				"a.yaml":      "layouts:\n  dev:\n    windows:\n      - name: one\n",
+				"b.yaml":      "layouts:\n  dev:\n    windows:\n      - name: two\n",
+			},
+			want: []string{"b.yaml:2:3", `layout "dev" conflicts with the one in`, "a.yaml"},
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"config.yaml": "include: [shared.yaml]\n",
+			},
+			want: []string{"shared.yaml", "file does not exist"},
+		},
+		{
+			name: "nested include",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml]\n",
+				"a.yaml":      "include: [b.yaml]\n",
+			},
+			want: []string{"a.yaml:1:1", "include can only be set in the main config"},
+		},
+		{
+			name: "error names the file",
+			files: map[string]string{
+				"config.yaml":       "projects: []\n",
+				"conf.d/teams.yaml": "layouts:\n  api:\n    extends: base\n",
+			},
+			want: []string{"teams.yaml: layout \"api\" extends unknown layout \"base\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			_, err := Load(filepath.Join(dir, "config.yaml"))
+			if err == nil {
+				t.Fatal("Load() error = nil")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error = %v, want substring %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": `
+include: [team.yaml]
+projects:
+  - name: api
+    path: /nonexistent
+    layout: dev
+`,
+		"team.yaml": `
+layouts:
+  dev:
+    windows:
+      - name: editor
+        panes:
+          - size: "130%"
+`,
+	})
+	main, team := filepath.Join(dir, "config.yaml"), filepath.Join(dir, "team.yaml")
+
+	issues, err := Validate(main)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	want := []Issue{
+		{File: main, Line: 5, Message: `project "api": path "/nonexistent" does not exist`},
+		{File: team, Line: 7, Message: `layout "dev", window "editor": size "130%" is outside 1%-100%`},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("Validate() = %v, want %d issues", issues, len(want))
+	}
+	for i, w := range want {
+		if got := issues[i]; got.File != w.File || got.Line != w.Line || got.Message != w.Message {
+			t.Errorf("issue %d = %v, want %v", i, got, w)
+		}
+	}
+}
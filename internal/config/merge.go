@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlIndent matches the indentation of the starter config.
+const yamlIndent = 2
+
+// nullTag is the tag of an empty YAML value, as in "layouts:" with nothing after.
+const nullTag = "!!null"
+
+// MergeLayout adds the layout under name to the YAML config in data, replacing
+// any layout of the same name, and returns the updated file. When proj is not
+// nil, the project of the same name is pointed at the layout, or proj is
+// appended if there is none. The rest of the file, comments included, is kept.
+// Empty data yields a config holding just the layout and project.
+func MergeLayout(data []byte, name string, layout Layout, proj *Project) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf(ErrParsingConfig, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := documentRoot(&doc)
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf(ErrFmtNotMapping, keyLayouts)
+	}
+
+	if proj != nil {
+		if err := mergeProject(ensureValue(root, keyProjects, yaml.SequenceNode), name, proj); err != nil {
+			return nil, err
+		}
+	}
+
+	var layoutNode yaml.Node
+	if err := layoutNode.Encode(layout); err != nil {
+		return nil, err
+	}
+	layouts := ensureValue(root, keyLayouts, yaml.MappingNode)
+	if existing := mappingValue(layouts, name); existing != nil {
+		*existing = layoutNode
+	} else {
+		layouts.Content = append(layouts.Content, scalar(name), &layoutNode)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeProject sets the layout of the project named proj.Name in the projects
+// sequence, leaving its other fields alone, or appends proj.
+func mergeProject(projects *yaml.Node, layout string, proj *Project) error {
+	for _, item := range projects.Content {
+		if n := mappingValue(item, keyName); n != nil && n.Value == proj.Name {
+			if v := mappingValue(item, keyLayout); v != nil {
+				*v = *scalar(layout)
+			} else {
+				item.Content = append(item.Content, scalar(keyLayout), scalar(layout))
+			}
+			return nil
+		}
+	}
+
+	p := *proj
+	p.Layout = layout
+	var node yaml.Node
+	if err := node.Encode(p); err != nil {
+		return err
+	}
+	projects.Content = append(projects.Content, &node)
+	return nil
+}
+
+// ensureValue returns the value node for key in the mapping n, adding an
+// empty node of the given kind when the key is missing or null.
+func ensureValue(n *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	if v := mappingValue(n, key); v != nil {
+		if v.Kind == yaml.ScalarNode && v.Tag == nullTag {
+			*v = yaml.Node{Kind: kind}
+		}
+		return v
+	}
+	v := &yaml.Node{Kind: kind}
+	n.Content = append(n.Content, scalar(key), v)
+	return v
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
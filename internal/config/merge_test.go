@@ -0,0 +1,112 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const mergeBase = `# tplm configuration
+projects:
+  - name: api # the backend
+    path: ~/src/api
+    layout: old
+    on_start:
+      - window: editor
+        command: nvim .
+
+layouts:
+  # kept as is
+  old:
+    windows:
+      - name: main
+`
+
+func TestMergeLayout(t *testing.T) {
+	layout := Layout{Windows: []Window{
+		{Name: "editor", Panes: []Pane{{Command: "nvim"}, {Split: "horizontal", Size: "30%"}}},
+		{Name: "server"},
+	}}
+
+	tests := []struct {
+		name     string
+		data     string
+		layout   string
+		proj     *Project
+		contains []string
+		check    func(t *testing.T, cfg Config)
+	}{
+		{
+			name:     "adds layout and keeps comments",
+			data:     mergeBase,
+			layout:   "snap",
+			contains: []string{"# tplm configuration", "# the backend", "# kept as is"},
+			check: func(t *testing.T, cfg Config) {
+				if len(cfg.Layouts) != 2 || len(cfg.Layouts["snap"].Windows) != 2 {
+					t.Errorf("layouts = %+v, want old and snap", cfg.Layouts)
+				}
+				if cfg.Projects[0].Layout != "old" {
+					t.Errorf("project layout = %q, want old", cfg.Projects[0].Layout)
+				}
+			},
+		},
+		{
+			name:   "replaces layout and repoints existing project",
+			data:   mergeBase,
+			layout: "old",
+			proj:   &Project{Name: "api", Path: "/elsewhere"},
+			check: func(t *testing.T, cfg Config) {
+				if got := cfg.Layouts["old"].Windows; len(got) != 2 || got[0].Panes[0].Command != "nvim" {
+					t.Errorf("layout old = %+v, want the snapshot", got)
+				}
+				p := cfg.Projects[0]
+				if len(cfg.Projects) != 1 || p.Path != "~/src/api" || len(p.OnStart) != 1 {
+					t.Errorf("projects = %+v, want api unchanged apart from its layout", cfg.Projects)
+				}
+			},
+		},
+		{
+			name:   "appends new project",
+			data:   mergeBase,
+			layout: "web",
+			proj:   &Project{Name: "web", Path: "/src/web"},
+			check: func(t *testing.T, cfg Config) {
+				if len(cfg.Projects) != 2 || !reflect.DeepEqual(cfg.Projects[1], Project{Name: "web", Path: "/src/web", Layout: "web"}) {
+					t.Errorf("projects = %+v, want web appended", cfg.Projects)
+				}
+			},
+		},
+		{
+			name:     "empty config",
+			layout:   "snap",
+			proj:     &Project{Name: "snap", Path: "/src/snap"},
+			contains: []string{"layouts:\n  snap:\n    windows:\n      - name: editor\n"},
+			check: func(t *testing.T, cfg Config) {
+				if len(cfg.Projects) != 1 || cfg.Projects[0].Layout != "snap" || len(cfg.Layouts) != 1 {
+					t.Errorf("config = %+v, want project and layout snap", cfg)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MergeLayout([]byte(tt.data), tt.layout, layout, tt.proj)
+			if err != nil {
+				t.Fatalf("MergeLayout() error = %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("output lacks %q:\n%s", want, out)
+				}
+			}
+			var cfg Config
+			if err := yaml.Unmarshal(out, &cfg); err != nil {
+				t.Fatalf("output does not parse: %v\n%s", err, out)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFile is a project's .tplm.yaml: the parts of a project definition that
+// can live next to its code. The global config's project entry wins over it
+// for every field both set.
+type RepoFile struct {
+	Name        string    `yaml:"name,omitempty"`    // session name when the project isn't in the global config
+	Layout      string    `yaml:"layout,omitempty"`  // layout of the global config, in place of windows
+	Windows     []Window  `yaml:"windows,omitempty"` // the project's own layout
+	Vars        Vars      `yaml:"vars,omitempty"`
+	Env         Env       `yaml:"env,omitempty"`
+	EnvFile     []string  `yaml:"env_file,omitempty"`
+	BeforeStart []string  `yaml:"before_start,omitempty"`
+	OnStart     []OnStart `yaml:"on_start,omitempty"`
+	OnStop      []OnStop  `yaml:"on_stop,omitempty"`
+}
+
+// repoCommands records the commands a project runs that its .tplm.yaml
+// defined, and the vars and env it sets, which the user must trust before
+// any of them take effect.
+type repoCommands struct {
+	file     string   // path of the .tplm.yaml
+	sum      string   // SHA-256 of its content and its env_file files, in hex
+	commands []string // described for the user, e.g. "before_start: make deps"
+}
+
+// RepoCommands returns the path of the project's .tplm.yaml and the commands,
+// vars and env it defines, or "" when the project takes none from one.
+func (p *Project) RepoCommands() (string, []string) {
+	if p.repo == nil {
+		return "", nil
+	}
+	return p.repo.file, p.repo.commands
+}
+
+// applyRepoFile fills in the project fields the global config leaves empty
+// from the .tplm.yaml in the project's directory, and returns the file. It
+// returns nil when there is none. Env and vars are merged, the global
+// config's values winning.
+func (c *Config) applyRepoFile(proj *Project) (*RepoFile, error) {
+	if proj.Path == "" {
+		return nil, nil
+	}
+	path := filepath.Join(proj.Path, RepoFileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return nil, nil // validation reports a path that isn't a directory
+	}
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadingConfig, err)
+	}
+	var repo RepoFile
+	if err := yaml.Unmarshal(data, &repo); err != nil {
+		return nil, fmt.Errorf(ErrFmtParsingFile, path, err)
+	}
+
+	var commands []string
+	if proj.Layout == "" {
+		switch {
+		case len(repo.Windows) > 0:
+			windows, err := c.useWindows(RepoFileName, repo.Windows)
+			if err != nil {
+				return nil, inFile(path, err)
+			}
+			proj.layout = &Layout{Windows: windows}
+			for _, w := range windows {
+				for _, name := range slices.Sorted(maps.Keys(w.Env)) {
+					commands = append(commands, fmt.Sprintf(FmtRepoWindowEnv, w.Name, name, w.Env[name]))
+				}
+				for i, pane := range w.Leaves() {
+					if pane.Command != "" {
+						commands = append(commands, fmt.Sprintf(FmtRepoPane, w.Name, pane.Command))
+					}
+					for _, name := range slices.Sorted(maps.Keys(pane.Env)) {
+						commands = append(commands, fmt.Sprintf(FmtRepoPaneEnv, w.Name, i, name, pane.Env[name]))
+					}
+				}
+			}
+		case repo.Layout != "":
+			proj.Layout = repo.Layout
+		}
+	}
+	// Vars reach commands through templates and env reaches every shell, so
+	// the ones the file sets need trust as much as its commands.
+	for _, name := range slices.Sorted(maps.Keys(repo.Vars)) {
+		if _, ok := proj.Vars[name]; !ok {
+			commands = append(commands, fmt.Sprintf(FmtRepoVar, name, repo.Vars[name]))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(repo.Env)) {
+		if _, ok := proj.Env[name]; !ok {
+			commands = append(commands, fmt.Sprintf(FmtRepoEnv, name, repo.Env[name]))
+		}
+	}
+	proj.Vars = Vars(Env(repo.Vars).With(Env(proj.Vars)))
+	proj.Env = repo.Env.With(proj.Env)
+	var envFiles []string
+	if len(proj.EnvFile) == 0 {
+		proj.EnvFile, envFiles = repo.EnvFile, repo.EnvFile
+		for _, file := range repo.EnvFile {
+			commands = append(commands, fmt.Sprintf(FmtRepoEnvFile, file))
+		}
+	}
+	if len(proj.BeforeStart) == 0 {
+		proj.BeforeStart = repo.BeforeStart
+		for _, cmd := range repo.BeforeStart {
+			commands = append(commands, fmt.Sprintf(FmtRepoBeforeStart, cmd))
+		}
+	}
+	if len(proj.OnStart) == 0 {
+		proj.OnStart = repo.OnStart
+		for _, cmd := range repo.OnStart {
+			commands = append(commands, fmt.Sprintf(FmtRepoOnStart, cmd.Window, cmd.Command))
+		}
+	}
+	if len(proj.OnStop) == 0 {
+		proj.OnStop = repo.OnStop
+		for _, step := range repo.OnStop {
+			switch {
+			case step.Run != "":
+				commands = append(commands, fmt.Sprintf(FmtRepoOnStopRun, step.Run))
+			case step.Keys != "":
+				commands = append(commands, fmt.Sprintf(FmtRepoOnStopKeys, step.Window, step.Keys))
+			}
+		}
+	}
+
+	if len(commands) > 0 {
+		proj.repo = &repoCommands{file: path, sum: repoSum(data, proj.Path, envFiles), commands: commands}
+	}
+	return &repo, nil
+}
+
+// repoSum returns the checksum trust is recorded with: of the .tplm.yaml's
+// content and of the env_file files it names, relative to dir, so a change
+// to any of them needs trust again. A file that can't be read counts as
+// empty.
+func repoSum(data []byte, dir string, envFiles []string) string {
+	h := sha256.New()
+	h.Write(data)
+	for _, file := range envFiles {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		content, _ := os.ReadFile(path)
+		fmt.Fprintf(h, "\x00%s\x00%d\x00", file, len(content))
+		h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ProjectForDir returns the project whose path is dir. When there is none but
+// dir has a .tplm.yaml, it adds a project for dir defined by that file, named
+// by its name or else after the directory.
+func (c *Config) ProjectForDir(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range c.Projects {
+		if filepath.Clean(c.Projects[i].Path) == dir {
+			return &c.Projects[i], nil
+		}
+	}
+
+	proj := Project{Path: dir}
+	repo, err := c.applyRepoFile(&proj)
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return nil, fmt.Errorf(ErrFmtNoRepoFile, dir, RepoFileName)
+	}
+	proj.Name = cmp.Or(repo.Name, sessionNameReplacer.Replace(filepath.Base(dir)))
+	if err := c.expandProject(&proj); err != nil {
+		return nil, err
+	}
+	c.Projects = append(c.Projects, proj)
+	return &c.Projects[len(c.Projects)-1], nil
+}
+
+// sessionNameReplacer replaces the characters tmux doesn't allow in session
+// names.
+var sessionNameReplacer = strings.NewReplacer(".", "_", ":", "_")
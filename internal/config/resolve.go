@@ -0,0 +1,108 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// resolveLayouts expands extends and use in every layout, so the rest of the
+// program sees plain window lists. A layout that can't be resolved is left as
+// written and reported in the returned error.
+func (c *Config) resolveLayouts() error {
+	resolved := make(map[string]Layout, len(c.Layouts))
+	var resolve func(name string, chain []string) (Layout, error)
+	resolve = func(name string, chain []string) (Layout, error) {
+		if l, ok := resolved[name]; ok {
+			return l, nil
+		}
+		if slices.Contains(chain, name) {
+			return Layout{}, fmt.Errorf(ErrFmtLayoutCycle, chain[0], strings.Join(append(chain, name), cycleSep))
+		}
+		layout := c.Layouts[name]
+		windows, err := c.useWindows(name, layout.Windows)
+		if err != nil {
+			return Layout{}, err
+		}
+		env := layout.Env
+		if layout.Extends != "" {
+			if _, ok := c.Layouts[layout.Extends]; !ok {
+				return Layout{}, fmt.Errorf(ErrFmtUnknownBase, name, layout.Extends)
+			}
+			base, err := resolve(layout.Extends, append(slices.Clip(chain), name))
+			if err != nil {
+				return Layout{}, err
+			}
+			windows, env = inheritWindows(base.Windows, windows), base.Env.With(env)
+		}
+		l := Layout{Env: env, Windows: windows}
+		resolved[name] = l
+		return l, nil
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(c.Layouts)) {
+		if _, err := resolve(name, nil); err != nil {
+			errs = append(errs, inFile(c.sources.layouts[name], err))
+		}
+	}
+	for name, l := range resolved {
+		c.Layouts[name] = l
+	}
+	return errors.Join(errs...)
+}
+
+// useWindows replaces the windows of the named layout that use a window
+// definition with the definition, overridden by the window's own fields.
+func (c *Config) useWindows(layout string, windows []Window) ([]Window, error) {
+	out := make([]Window, len(windows))
+	for i, w := range windows {
+		if w.Use == "" {
+			out[i] = w
+			continue
+		}
+		def, ok := c.Windows[w.Use]
+		if !ok {
+			return nil, fmt.Errorf(ErrFmtUnknownWindow, layout, w.Use)
+		}
+		if def.Use != "" {
+			return nil, fmt.Errorf(ErrFmtNestedUse, w.Use, def.Use)
+		}
+		out[i] = useWindow(w.Use, def, w)
+	}
+	return out, nil
+}
+
+// useWindow returns the window definition named name with the fields w sets
+// on top of it. A definition without a name is named after its key.
+func useWindow(name string, def, w Window) Window {
+	out := def
+	out.Name = cmp.Or(w.Name, def.Name, name)
+	out.Root = cmp.Or(w.Root, def.Root)
+	out.Env = def.Env.With(w.Env)
+	if len(w.Panes) > 0 || w.Tree != nil {
+		out.Panes, out.Tree = w.Panes, w.Tree
+	}
+	out.Layout = cmp.Or(w.Layout, def.Layout)
+	out.ReapplyLayout = def.ReapplyLayout || w.ReapplyLayout
+	return out
+}
+
+// inheritWindows returns the windows of a base layout with those of a layout
+// extending it: a window named like one of the base's replaces it in place,
+// the others come after the base's.
+func inheritWindows(base, own []Window) []Window {
+	out := slices.Clone(base)
+	for _, w := range own {
+		i := slices.IndexFunc(out, func(b Window) bool { return b.Name == w.Name })
+		if i < 0 {
+			out = append(out, w)
+		} else {
+			out[i] = w
+		}
+	}
+	return out
+}
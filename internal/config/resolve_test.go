@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadResolvesLayouts(t *testing.T) {
+	content := `
+windows:
+  editor:
+    panes:
+      - command: nvim .
+      - split: horizontal
+        size: "30%"
+  git:
+    name: lazygit
+    panes:
+      - command: lazygit
+
+layouts:
+  base:
+    env:
+      PAGER: less
+    windows:
+      - use: editor
+      - use: git
+  service:
+    extends: base
+    env:
+      APP_ENV: dev
+    windows:
+      - name: server
+        panes:
+          - command: go run .
+  api:
+    extends: service
+    windows:
+      - use: editor
+        root: api
+      - name: server
+        panes:
+          - command: make run
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	editor := Window{Name: "editor", Panes: []Pane{{Command: "nvim ."}, {Split: "horizontal", Size: "30%"}}}
+	git := Window{Name: "lazygit", Panes: []Pane{{Command: "lazygit"}}}
+	apiEditor := editor
+	apiEditor.Root = "api"
+	tests := []struct {
+		layout  string
+		windows []Window
+		env     Env
+	}{
+		{"base", []Window{editor, git}, Env{"PAGER": "less"}},
+		{"service", []Window{editor, git, {Name: "server", Panes: []Pane{{Command: "go run ."}}}}, Env{"PAGER": "less", "APP_ENV": "dev"}},
+		{"api", []Window{apiEditor, git, {Name: "server", Panes: []Pane{{Command: "make run"}}}}, Env{"PAGER": "less", "APP_ENV": "dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			got := cfg.Layouts[tt.layout]
+			if got.Extends != "" {
+				t.Errorf("extends = %q, want it resolved", got.Extends)
+			}
+			if !reflect.DeepEqual(got.Windows, tt.windows) {
+				t.Errorf("windows = %+v, want %+v", got.Windows, tt.windows)
+			}
+			if !reflect.DeepEqual(got.Env, tt.env) {
+				t.Errorf("env = %v, want %v", got.Env, tt.env)
+			}
+		})
+	}
+}
+
+func TestLoadLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name: "extends cycle",
+			content: `
+layouts:
+  a:
+    extends: b
+  b:
+    extends: a
+`,
+			want: `layout "a": extends cycle a -> b -> a`,
+		},
+		{
+			name: "unknown base",
+			content: `
+layouts:
+  api:
+    extends: bsae
+`,
+			want: `layout "api" extends unknown layout "bsae"`,
+		},
+		{
+			name: "unknown window",
+			content: `
+layouts:
+  api:
+    windows:
+      - use: edtior
+`,
+			want: `layout "api" uses unknown window "edtior"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
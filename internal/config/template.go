@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// templateData is what templates in a project's definitions can refer to, as
+// {{.Project.Path}} or {{.Vars.service}}.
+type templateData struct {
+	Project *Project
+	Vars    Vars
+}
+
+// expandProject expands the templates in the project's on_start and on_stop
+// steps and in the windows of its layout, which GetLayout returns from then on.
+func (c *Config) expandProject(proj *Project) error {
+	e := expander{data: templateData{Project: proj, Vars: proj.Vars}}
+	layout := e.layout(c.GetLayout(proj))
+	for i := range proj.OnStart {
+		proj.OnStart[i].Window = e.expand(proj.OnStart[i].Window)
+		proj.OnStart[i].Command = e.expand(proj.OnStart[i].Command)
+	}
+	for i := range proj.OnStop {
+		proj.OnStop[i].Window = e.expand(proj.OnStop[i].Window)
+		proj.OnStop[i].Run = e.expand(proj.OnStop[i].Run)
+	}
+	if e.err != nil {
+		return fmt.Errorf(ErrFmtProjectTmpl, proj.Name, e.err)
+	}
+	proj.layout = &layout
+	return nil
+}
+
+// expander expands templates against data, keeping the first error.
+type expander struct {
+	data templateData
+	err  error
+}
+
+// expand returns s with its template executed. Strings without actions are
+// returned as they are.
+func (e *expander) expand(s string) string {
+	if e.err != nil || !strings.Contains(s, templateOpen) {
+		return s
+	}
+	t, err := template.New(e.data.Project.Name).Option(templateMissingKey).Parse(s)
+	if err != nil {
+		e.err = fmt.Errorf(ErrFmtTemplate, s, err)
+		return s
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, e.data); err != nil {
+		e.err = fmt.Errorf(ErrFmtTemplate, s, err)
+		return s
+	}
+	return b.String()
+}
+
+// layout returns a copy of l with the window names, roots and pane commands
+// expanded.
+func (e *expander) layout(l Layout) Layout {
+	l.Windows = slices.Clone(l.Windows)
+	for i := range l.Windows {
+		w := &l.Windows[i]
+		w.Name = e.expand(w.Name)
+		w.Root = e.expand(w.Root)
+		w.Panes = slices.Clone(w.Panes)
+		for j := range w.Panes {
+			w.Panes[j].Command = e.expand(w.Panes[j].Command)
+			w.Panes[j].Root = e.expand(w.Panes[j].Root)
+		}
+		if w.Tree != nil {
+			tree := e.tree(*w.Tree)
+			w.Tree = &tree
+		}
+	}
+	return l
+}
+
+func (e *expander) tree(n PaneNode) PaneNode {
+	n.Command = e.expand(n.Command)
+	n.Root = e.expand(n.Root)
+	n.Children = slices.Clone(n.Children)
+	for i := range n.Children {
+		n.Children[i] = e.tree(n.Children[i])
+	}
+	return n
+}
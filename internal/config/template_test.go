@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadExpandsTemplates(t *testing.T) {
+	content := `
+projects:
+  - name: billing
+    path: /src/billing
+    layout: go-service
+    vars:
+      service: billing-api
+    on_start:
+      - window: "{{.Vars.service}}"
+        command: make migrate
+  - name: plain
+    path: /src/plain
+    layout: go-service
+    vars:
+      service: plain
+
+layouts:
+  go-service:
+    windows:
+      - name: "{{.Vars.service}}"
+        root: cmd/{{.Vars.service}}
+        panes:
+          - command: go run ./cmd/{{.Vars.service}}
+      - name: test
+        tree:
+          children:
+            - command: cd {{.Project.Path}} && go test ./...
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	proj := cfg.FindProject("billing")
+	want := Layout{Windows: []Window{
+		{Name: "billing-api", Root: "cmd/billing-api", Panes: []Pane{{Command: "go run ./cmd/billing-api"}}},
+		{Name: "test", Tree: &PaneNode{Children: []PaneNode{{Command: "cd /src/billing && go test ./..."}}}},
+	}}
+	if got := cfg.GetLayout(proj); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetLayout() = %+v, want %+v", got, want)
+	}
+	if got := proj.OnStart[0].Window; got != "billing-api" {
+		t.Errorf("on_start window = %q, want %q", got, "billing-api")
+	}
+	if got := cfg.GetLayout(cfg.FindProject("plain")).Windows[0].Name; got != "plain" {
+		t.Errorf("plain window = %q, want %q", got, "plain")
+	}
+	if got := cfg.Layouts["go-service"].Windows[0].Name; got != "{{.Vars.service}}" {
+		t.Errorf("layout window = %q, want the shared layout left as written", got)
+	}
+}
+
+func TestLoadUndefinedVar(t *testing.T) {
+	content := `
+projects:
+  - name: billing
+    path: /src/billing
+    layout: go-service
+layouts:
+  go-service:
+    windows:
+      - name: server
+        panes:
+          - command: go run ./cmd/{{.Vars.service}}
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), `project "billing"`) || !strings.Contains(err.Error(), `no entry for key "service"`) {
+		t.Errorf("Load() error = %v, want undefined variable error", err)
+	}
+}
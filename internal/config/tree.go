@@ -0,0 +1,31 @@
+package config
+
+// Leaves returns the window's panes in layout order: its panes list, or the
+// panes of its tree, depth first. Tree panes carry no split or size.
+func (w Window) Leaves() []Pane {
+	if w.Tree == nil {
+		return w.Panes
+	}
+	var panes []Pane
+	w.Tree.walkLeaves(func(n PaneNode) {
+		panes = append(panes, Pane{Command: n.Command, Root: n.Root, Env: n.Env})
+	})
+	return panes
+}
+
+// LeafCount returns how many panes the node lays out.
+func (n PaneNode) LeafCount() int {
+	count := 0
+	n.walkLeaves(func(PaneNode) { count++ })
+	return count
+}
+
+func (n PaneNode) walkLeaves(fn func(PaneNode)) {
+	if len(n.Children) == 0 {
+		fn(n)
+		return
+	}
+	for _, child := range n.Children {
+		child.walkLeaves(fn)
+	}
+}
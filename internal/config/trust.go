@@ -0,0 +1,90 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrUntrusted is returned when a project would run commands from a
+// .tplm.yaml the user hasn't trusted.
+var ErrUntrusted = errors.New("commands not trusted")
+
+// TrustFilePath returns ~/.config/tplm/trusted, the file recording the
+// .tplm.yaml files the user trusts, each with the checksum of its trusted content.
+func TrustFilePath() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), TrustFile)
+}
+
+// CheckTrusted returns an error wrapping ErrUntrusted when the project runs
+// commands from a .tplm.yaml that isn't trusted as it is now. Trust is lost
+// whenever the file changes.
+func CheckTrusted(proj *Project) error {
+	if proj.repo == nil {
+		return nil
+	}
+	trusted, err := readTrusted()
+	if err != nil {
+		return err
+	}
+	if trusted[proj.repo.file] != proj.repo.sum {
+		return fmt.Errorf(ErrFmtUntrusted, proj.repo.file, ErrUntrusted)
+	}
+	return nil
+}
+
+// TrustProject records the project's .tplm.yaml, as it is now, as trusted.
+func TrustProject(proj *Project) error {
+	if proj.repo == nil {
+		return nil
+	}
+	trusted, err := readTrusted()
+	if err != nil {
+		return err
+	}
+	trusted[proj.repo.file] = proj.repo.sum
+
+	var b strings.Builder
+	for _, file := range slices.Sorted(maps.Keys(trusted)) {
+		fmt.Fprintf(&b, trustLineFmt, trusted[file], file)
+	}
+	path := TrustFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf(ErrFmtTrustFile, err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf(ErrFmtTrustFile, err)
+	}
+	return nil
+}
+
+// readTrusted returns the trusted files mapped to their checksums. Each line
+// of the trust file is a checksum and a path.
+func readTrusted() (map[string]string, error) {
+	trusted := make(map[string]string)
+	f, err := os.Open(TrustFilePath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return trusted, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(ErrFmtTrustFile, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if sum, file, ok := strings.Cut(scanner.Text(), trustSep); ok {
+			trusted[file] = sum
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(ErrFmtTrustFile, err)
+	}
+	return trusted, nil
+}
@@ -2,38 +2,107 @@ package config
 
 // Config is the top-level YAML configuration.
 type Config struct {
-	Projects []Project         `yaml:"projects"`
-	Layouts  map[string]Layout `yaml:"layouts"`
+	TmuxSocket string            `yaml:"tmux_socket,omitempty"` // socket name or path of the tmux server to use
+	Projects   []Project         `yaml:"projects"`
+	Layouts    map[string]Layout `yaml:"layouts"`
+	Windows    map[string]Window `yaml:"windows,omitempty"`  // window definitions layouts can use
+	Include    []string          `yaml:"include,omitempty"`  // globs of more config files, relative to this one
+	Discover   *Discover         `yaml:"discover,omitempty"` // finds more projects in directory trees
+
+	sources  sources // files the definitions were read from, set by Load
+	warnings []error // problems Load skipped over, such as broken discovered projects
 }
 
 // Project defines a workspace entry.
 type Project struct {
-	Name    string    `yaml:"name"`
-	Path    string    `yaml:"path"`
-	Layout  string    `yaml:"layout"`
-	OnStart []OnStart `yaml:"on_start,omitempty"`
+	Name        string    `yaml:"name"`
+	Path        string    `yaml:"path"`
+	Layout      string    `yaml:"layout"`
+	TmuxSocket  string    `yaml:"tmux_socket,omitempty"`  // overrides Config.TmuxSocket for this project
+	Vars        Vars      `yaml:"vars,omitempty"`         // values templates in the project's layout and steps can use
+	Env         Env       `yaml:"env,omitempty"`          // session environment; overrides the layout's and env_file's
+	EnvFile     []string  `yaml:"env_file,omitempty"`     // dotenv files, relative to Path
+	BeforeStart []string  `yaml:"before_start,omitempty"` // shell commands run in Path before the session is created
+	OnStart     []OnStart `yaml:"on_start,omitempty"`
+	OnStop      []OnStop  `yaml:"on_stop,omitempty"`
+
+	layout *Layout       // the project's layout with its templates expanded, set by Load
+	repo   *repoCommands // what the project runs from its .tplm.yaml, set by Load
+
+	discovered bool // found by Config.Discover rather than listed in the config
 }
 
 // OnStart defines a command to run in a specific window on session creation.
 type OnStart struct {
-	Window  string `yaml:"window"`
-	Command string `yaml:"command"`
+	Window  string   `yaml:"window"`
+	Command string   `yaml:"command"`
+	WaitFor *WaitFor `yaml:"wait_for,omitempty"` // holds back the next commands until it is met
+}
+
+// WaitFor is a condition checked after an on_start command is sent. It sets
+// exactly one of Port, File, Output or Delay.
+type WaitFor struct {
+	Port    string `yaml:"port,omitempty"`    // "5432" (on localhost) or "host:5432" accepting TCP connections
+	File    string `yaml:"file,omitempty"`    // path that exists, relative to the project path
+	Output  string `yaml:"output,omitempty"`  // regular expression matched against the window's pane output
+	Delay   string `yaml:"delay,omitempty"`   // fixed time to wait, e.g. "2s"
+	Timeout string `yaml:"timeout,omitempty"` // longest time to wait for Port, File or Output; default 30s
+}
+
+// OnStop defines a step run before the project's session is killed. A step
+// does one thing: send keys to every pane of a window, wait for a window's
+// panes to return to their shell, or run a shell command in the project path.
+type OnStop struct {
+	Window string `yaml:"window,omitempty"` // window that keys and wait act on
+	Keys   string `yaml:"keys,omitempty"`   // tmux key names, e.g. "C-c"
+	Wait   string `yaml:"wait,omitempty"`   // longest time to wait, e.g. "10s"
+	Run    string `yaml:"run,omitempty"`    // shell command
 }
 
 // Layout defines a set of windows and their pane splits.
 type Layout struct {
+	Extends string   `yaml:"extends,omitempty"` // layout whose windows and env this one starts from
+	Env     Env      `yaml:"env,omitempty"`     // session environment of projects using the layout
 	Windows []Window `yaml:"windows"`
 }
 
 // Window defines a named window with pane splits.
 type Window struct {
-	Name  string `yaml:"name"`
-	Panes []Pane `yaml:"panes"`
+	Use   string    `yaml:"use,omitempty"` // window definition this window is, with its other fields on top
+	Name  string    `yaml:"name"`
+	Root  string    `yaml:"root,omitempty"` // working directory, relative to the project path
+	Env   Env       `yaml:"env,omitempty"`  // environment of every pane in the window
+	Panes []Pane    `yaml:"panes,omitempty"`
+	Tree  *PaneNode `yaml:"tree,omitempty"` // nested splits, in place of panes
+
+	// Layout is a tmux layout name such as "tiled", or a layout string as
+	// printed by #{window_layout}, selected once the panes are split.
+	Layout        string `yaml:"layout,omitempty"`
+	ReapplyLayout bool   `yaml:"reapply_layout,omitempty"` // select the layout again after on_start
 }
 
 // Pane defines a single pane with optional split direction and size.
 type Pane struct {
 	Split   string `yaml:"split,omitempty"`   // "horizontal" or "vertical"
-	Size    string `yaml:"size,omitempty"`     // e.g. "70%"
+	Size    string `yaml:"size,omitempty"`    // e.g. "70%"
 	Command string `yaml:"command,omitempty"` // optional command to run on pane startup
+	Root    string `yaml:"root,omitempty"`    // overrides the window's root for this pane
+	Env     Env    `yaml:"env,omitempty"`     // overrides the window's env for this pane
+}
+
+// PaneNode is a node of a window's pane tree. A node with children divides
+// its area between them; a node without children is a pane.
+type PaneNode struct {
+	Split    string     `yaml:"split,omitempty"` // children side by side ("horizontal") or stacked ("vertical")
+	Size     string     `yaml:"size,omitempty"`  // share of the parent's area, e.g. "30%"
+	Children []PaneNode `yaml:"children,omitempty"`
+	Command  string     `yaml:"command,omitempty"` // optional command to run on pane startup
+	Root     string     `yaml:"root,omitempty"`    // overrides the window's root for this pane
+	Env      Env        `yaml:"env,omitempty"`     // overrides the window's env for this pane
 }
+
+// Vars maps template variable names to values.
+type Vars map[string]string
+
+// Env maps environment variable names to values.
+type Env map[string]string
@@ -0,0 +1,641 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML keys looked up while mapping decoded values back to source positions.
+const (
+	keyProjects   = "projects"
+	keyInclude    = "include"
+	keyTmuxSocket = "tmux_socket"
+	keyDiscover   = "discover"
+	keyRoots      = "roots"
+	keyMaxDepth   = "max_depth"
+	keyByMarker   = "marker_layouts"
+	keyCacheTTL   = "cache_ttl"
+	keyLayouts    = "layouts"
+	keyName       = "name"
+	keyPath       = "path"
+	keyLayout     = "layout"
+	keyOnStart    = "on_start"
+	keyOnStop     = "on_stop"
+	keyWait       = "wait"
+	keyWaitFor    = "wait_for"
+	keyPort       = "port"
+	keyOutput     = "output"
+	keyDelay      = "delay"
+	keyTimeout    = "timeout"
+	keyEnv        = "env"
+	keyEnvFile    = "env_file"
+	keyWindow     = "window"
+	keyWindows    = "windows"
+	keyPanes      = "panes"
+	keyTree       = "tree"
+	keyExtends    = "extends"
+	keyUse        = "use"
+	keyReapply    = "reapply_layout"
+	keyChildren   = "children"
+	keySplit      = "split"
+	keySize       = "size"
+)
+
+// maxPort is the highest TCP port a wait_for can name.
+const maxPort = 65535
+
+var sizePattern = regexp.MustCompile(`^(\d+)%$`)
+
+// layoutCell matches a pane in a tmux layout string: WxH,X,Y,ID. Cells that
+// hold other cells end in a bracket instead of an ID.
+var layoutCell = regexp.MustCompile(`\d+x\d+,\d+,\d+,\d+`)
+
+// windowsIssueLabel stands in for the layout name in issues about window
+// definitions.
+const windowsIssueLabel = "(windows)"
+
+// builtinLayouts are the layout names select-layout accepts.
+var builtinLayouts = []string{LayoutEvenHorizontal, LayoutEvenVertical, LayoutMainHorizontal, LayoutMainVertical, LayoutTiled}
+
+// layoutChecksumLen is the length of the hex checksum leading a layout string.
+const layoutChecksumLen = 4
+
+// Issue is a single problem found by Validate, positioned in the config file.
+type Issue struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// String formats the issue as "file:line:column: message".
+func (i Issue) String() string {
+	return fmt.Sprintf(IssueFmtPosition, i.File, i.Line, i.Column, i.Message)
+}
+
+// Validate reads the config file at path and the files it includes, and
+// reports every semantic problem it finds, sorted by file and position. The
+// error is non-nil only when a file cannot be read or parsed; a config with
+// problems returns them as issues.
+func Validate(path string) ([]Issue, error) {
+	cfg, files, merged, err := loadFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, projects := range append([][]Project{cfg.Projects}, fileProjects(files)...) {
+			for i := range projects {
+				projects[i].Path = expandHome(projects[i].Path, home)
+			}
+		}
+	}
+
+	// Each file's definitions are checked as written, against the merged
+	// config; projects see layouts resolved. What keeps a layout from
+	// resolving is reported by checkLayouts.
+	v := validator{cfg: cfg, layouts: maps.Clone(cfg.Layouts)}
+	_ = cfg.resolveLayouts()
+	var issues []Issue
+	for _, f := range files {
+		v.file, v.part, v.issues = f.path, &f.cfg, nil
+		for _, issue := range merged {
+			if issue.File == f.path {
+				v.issues = append(v.issues, issue)
+			}
+		}
+		root := documentRoot(&f.doc)
+		v.checkProjects(mappingValue(root, keyProjects))
+		if v.part.Discover != nil && f == files[0] {
+			v.checkDiscover(v.part.Discover, mappingValue(root, keyDiscover))
+		}
+		v.checkLayouts(mappingValue(root, keyLayouts))
+		v.checkWindowDefs(mappingValue(root, keyWindows))
+		v.sortIssues()
+		issues = append(issues, v.issues...)
+	}
+	return issues, nil
+}
+
+// fileProjects returns the projects of each file.
+func fileProjects(files []*configFile) [][]Project {
+	out := make([][]Project, len(files))
+	for i, f := range files {
+		out[i] = f.cfg.Projects
+	}
+	return out
+}
+
+// validator accumulates issues for one config file.
+type validator struct {
+	file    string
+	part    *Config           // the file's definitions, as written
+	cfg     *Config           // the definitions of all files, merged
+	layouts map[string]Layout // merged, before extends and use are resolved
+	issues  []Issue
+}
+
+func (v *validator) report(n *yaml.Node, format string, args ...any) {
+	issue := Issue{File: v.file, Message: fmt.Sprintf(format, args...)}
+	if n != nil {
+		issue.Line = n.Line
+		issue.Column = n.Column
+	}
+	v.issues = append(v.issues, issue)
+}
+
+// sortIssues sorts the issues by position.
+func (v *validator) sortIssues() {
+	sort.SliceStable(v.issues, func(a, b int) bool {
+		if v.issues[a].Line != v.issues[b].Line {
+			return v.issues[a].Line < v.issues[b].Line
+		}
+		return v.issues[a].Column < v.issues[b].Column
+	})
+}
+
+func (v *validator) checkProjects(seq *yaml.Node) {
+	firstLine := make(map[string]int)
+	for i := range v.part.Projects {
+		proj := &v.part.Projects[i]
+		node := sequenceItem(seq, i)
+
+		if proj.Name == "" {
+			v.report(node, IssueEmptyProjectName)
+		} else if line, dup := firstLine[proj.Name]; dup {
+			v.report(fieldNode(node, keyName), IssueDuplicateProject, proj.Name, line)
+		} else {
+			firstLine[proj.Name] = lineOf(fieldNode(node, keyName))
+		}
+
+		v.checkProjectPath(proj, node)
+		v.checkEnv(fmt.Sprintf(IssueFmtProject, proj.Name), proj.Env, mappingValue(node, keyEnv))
+		v.checkEnvFiles(proj, mappingValue(node, keyEnvFile))
+		if _, err := v.cfg.applyRepoFile(proj); err != nil {
+			v.report(node, IssueRepoFile, proj.Name, err)
+		}
+		if err := v.cfg.expandProject(proj); err != nil {
+			v.report(node, IssueTemplate, err)
+		}
+
+		layoutName := defaultLayoutIssueLabel
+		if proj.Layout != "" {
+			if _, ok := v.cfg.Layouts[proj.Layout]; !ok {
+				v.report(fieldNode(node, keyLayout), IssueUnknownLayout, proj.Name, proj.Layout)
+				continue
+			}
+			layoutName = proj.Layout
+		}
+
+		layout := v.cfg.GetLayout(proj)
+		v.checkRoots(proj, layout, fieldNode(node, keyLayout))
+		onStart := mappingValue(node, keyOnStart)
+		for j, cmd := range proj.OnStart {
+			if !layoutHasWindow(layout, cmd.Window) {
+				v.report(fieldNode(cmp.Or(sequenceItem(onStart, j), node), keyWindow), IssueOnStartNoWindow, proj.Name, cmd.Window, layoutName)
+			}
+			if cmd.WaitFor != nil {
+				v.checkWaitFor(proj.Name, cmd, cmp.Or(mappingValue(sequenceItem(onStart, j), keyWaitFor), node))
+			}
+		}
+
+		onStop := mappingValue(node, keyOnStop)
+		for j, step := range proj.OnStop {
+			v.checkOnStop(proj.Name, layout, layoutName, step, j, cmp.Or(sequenceItem(onStop, j), node))
+		}
+	}
+}
+
+func (v *validator) checkWaitFor(project string, cmd OnStart, node *yaml.Node) {
+	w := cmd.WaitFor
+	conditions := 0
+	for _, set := range []bool{w.Port != "", w.File != "", w.Output != "", w.Delay != ""} {
+		if set {
+			conditions++
+		}
+	}
+	if conditions != 1 {
+		v.report(node, IssueWaitForCondition, project, cmd.Window)
+	}
+
+	if w.Port != "" {
+		port := w.Port
+		if _, p, err := net.SplitHostPort(port); err == nil {
+			port = p
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > maxPort {
+			v.report(fieldNode(node, keyPort), IssueWaitForPort, project, w.Port)
+		}
+	}
+	if w.Output != "" {
+		if _, err := regexp.Compile(w.Output); err != nil {
+			v.report(fieldNode(node, keyOutput), IssueWaitForOutput, project, w.Output, err)
+		}
+	}
+	for key, value := range map[string]string{keyDelay: w.Delay, keyTimeout: w.Timeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			v.report(fieldNode(node, key), IssueWaitForDuration, project, key, value)
+		}
+	}
+}
+
+func (v *validator) checkOnStop(project string, layout Layout, layoutName string, step OnStop, i int, node *yaml.Node) {
+	actions := 0
+	for _, set := range []bool{step.Keys != "", step.Wait != "", step.Run != ""} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		v.report(node, IssueOnStopAction, project, i+1)
+		return
+	}
+
+	if step.Wait != "" {
+		if d, err := time.ParseDuration(step.Wait); err != nil || d <= 0 {
+			v.report(fieldNode(node, keyWait), IssueOnStopWait, project, step.Wait)
+		}
+	}
+	if step.Run != "" {
+		return
+	}
+	if step.Window == "" {
+		v.report(node, IssueOnStopNeedsWindow, project, i+1)
+	} else if !layoutHasWindow(layout, step.Window) {
+		v.report(fieldNode(node, keyWindow), IssueOnStopNoWindow, project, step.Window, layoutName)
+	}
+}
+
+func (v *validator) checkProjectPath(proj *Project, node *yaml.Node) {
+	if proj.Path == "" {
+		v.report(node, IssueMissingPath, proj.Name)
+		return
+	}
+	info, err := os.Stat(proj.Path)
+	switch {
+	case err != nil:
+		v.report(fieldNode(node, keyPath), IssuePathNotFound, proj.Name, proj.Path)
+	case !info.IsDir():
+		v.report(fieldNode(node, keyPath), IssuePathNotDir, proj.Name, proj.Path)
+	}
+}
+
+// checkDiscover reports discover roots that aren't directories, layouts that
+// don't exist and settings out of range.
+func (v *validator) checkDiscover(d *Discover, node *yaml.Node) {
+	home, _ := os.UserHomeDir()
+	roots := mappingValue(node, keyRoots)
+	for i, root := range d.Roots {
+		if home != "" {
+			root = expandHome(root, home)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			v.report(cmp.Or(sequenceItem(roots, i), node), IssueDiscoverRoot, d.Roots[i])
+		}
+	}
+	if d.MaxDepth < 0 {
+		v.report(fieldNode(node, keyMaxDepth), IssueDiscoverDepth, d.MaxDepth)
+	}
+	if d.Layout != "" {
+		if _, ok := v.cfg.Layouts[d.Layout]; !ok {
+			v.report(fieldNode(node, keyLayout), IssueDiscoverLayout, d.Layout)
+		}
+	}
+	byMarker := mappingValue(node, keyByMarker)
+	for _, marker := range slices.Sorted(maps.Keys(d.MarkerLayouts)) {
+		if layout := d.MarkerLayouts[marker]; layout != "" {
+			if _, ok := v.cfg.Layouts[layout]; !ok {
+				v.report(cmp.Or(mappingValue(byMarker, marker), node), IssueDiscoverLayout, layout)
+			}
+		}
+	}
+	if d.CacheTTL != "" {
+		if ttl, err := time.ParseDuration(d.CacheTTL); err != nil || ttl < 0 {
+			v.report(fieldNode(node, keyCacheTTL), IssueDiscoverTTL, d.CacheTTL)
+		}
+	}
+}
+
+// checkRoots reports window and pane roots of the project's layout that
+// aren't directories, resolving relative ones against the project path.
+func (v *validator) checkRoots(proj *Project, layout Layout, node *yaml.Node) {
+	if proj.Path == "" {
+		return
+	}
+	check := func(window, root string) {
+		if root == "" {
+			return
+		}
+		dir := root
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(proj.Path, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			v.report(node, IssueRootNotFound, proj.Name, root, window)
+		}
+	}
+	for _, win := range layout.Windows {
+		check(win.Name, win.Root)
+		for _, pane := range win.Leaves() {
+			check(win.Name, pane.Root)
+		}
+	}
+}
+
+// checkEnv reports variable names that can't be set in an environment. Each
+// issue points at the offending key.
+func (v *validator) checkEnv(label string, env Env, node *yaml.Node) {
+	for name := range env {
+		if !ValidEnvName(name) {
+			v.report(mappingKey(node, name), IssueEnvName, label, name)
+		}
+	}
+}
+
+// checkEnvFiles reports env_file entries that can't be read or parsed.
+func (v *validator) checkEnvFiles(proj *Project, seq *yaml.Node) {
+	for i, file := range proj.EnvFile {
+		if _, err := readEnvFile(proj.Path, file); err != nil {
+			v.report(sequenceItem(seq, i), IssueEnvFile, proj.Name, file, err)
+		}
+	}
+}
+
+func (v *validator) checkLayouts(layouts *yaml.Node) {
+	if layouts == nil || layouts.Kind != yaml.MappingNode {
+		return
+	}
+	for k := 0; k+1 < len(layouts.Content); k += 2 {
+		name := layouts.Content[k].Value
+		layout, ok := v.part.Layouts[name]
+		if !ok {
+			continue
+		}
+		node := layouts.Content[k+1]
+		if layout.Extends != "" {
+			if _, ok := v.layouts[layout.Extends]; !ok {
+				v.report(fieldNode(node, keyExtends), IssueUnknownBase, name, layout.Extends)
+			} else if v.extendsCycle(name) {
+				v.report(fieldNode(node, keyExtends), IssueLayoutCycle, name)
+			}
+		}
+		v.checkEnv(fmt.Sprintf(IssueFmtLayout, name), layout.Env, mappingValue(node, keyEnv))
+		windows := mappingValue(node, keyWindows)
+		for i, win := range layout.Windows {
+			winNode := sequenceItem(windows, i)
+			resolved := win
+			if win.Use != "" {
+				def, ok := v.cfg.Windows[win.Use]
+				if !ok {
+					v.report(fieldNode(winNode, keyUse), IssueUnknownWindow, name, win.Use)
+					continue
+				}
+				resolved = useWindow(win.Use, def, win)
+			}
+			v.checkWindow(name, i, win, resolved, winNode)
+		}
+	}
+}
+
+// checkWindowDefs checks the window definitions layouts can use. Issues name
+// the definitions as windows of windowsIssueLabel.
+func (v *validator) checkWindowDefs(defs *yaml.Node) {
+	if defs == nil || defs.Kind != yaml.MappingNode {
+		return
+	}
+	for k := 0; k+1 < len(defs.Content); k += 2 {
+		name := defs.Content[k].Value
+		def, ok := v.part.Windows[name]
+		if !ok {
+			continue
+		}
+		node := defs.Content[k+1]
+		if def.Use != "" {
+			v.report(fieldNode(node, keyUse), IssueNestedUse, name)
+		}
+		def.Name = cmp.Or(def.Name, name)
+		v.checkWindow(windowsIssueLabel, 0, def, def, node)
+	}
+}
+
+// extendsCycle reports whether following extends from the named layout leads
+// back to it.
+func (v *validator) extendsCycle(name string) bool {
+	seen := make(map[string]bool)
+	for cur := v.layouts[name].Extends; cur != "" && !seen[cur]; cur = v.layouts[cur].Extends {
+		if cur == name {
+			return true
+		}
+		seen[cur] = true
+	}
+	return false
+}
+
+// checkWindow checks the i-th window of a layout, its panes and pane tree, as
+// written in win. Its name and tmux layout are checked as resolved, since a
+// window that uses a definition gets them and its panes from there.
+func (v *validator) checkWindow(layout string, i int, win, resolved Window, node *yaml.Node) {
+	if resolved.Name == "" {
+		v.report(node, IssueEmptyWindowName, layout, i)
+	}
+	v.checkEnv(fmt.Sprintf(IssueFmtWindow, layout, resolved.Name), win.Env, mappingValue(node, keyEnv))
+	v.checkWindowLayout(layout, resolved, node)
+	panes := mappingValue(node, keyPanes)
+	for j, pane := range win.Panes {
+		paneNode := sequenceItem(panes, j)
+		v.checkPane(layout, resolved.Name, pane, paneNode)
+		v.checkEnv(fmt.Sprintf(IssueFmtPane, layout, resolved.Name, j), pane.Env, mappingValue(paneNode, keyEnv))
+	}
+	if win.Tree != nil {
+		if len(win.Panes) > 0 {
+			v.report(mappingKey(node, keyTree), IssueTreeAndPanes, layout, resolved.Name)
+		}
+		leaf := 0
+		v.checkTree(layout, resolved.Name, *win.Tree, mappingValue(node, keyTree), &leaf)
+	}
+}
+
+// checkWindowLayout checks the tmux layout a window selects: a built-in
+// name, or a layout string with an intact checksum and one cell per pane.
+func (v *validator) checkWindowLayout(layout string, win Window, node *yaml.Node) {
+	if win.Layout == "" {
+		if win.ReapplyLayout {
+			v.report(fieldNode(node, keyReapply), IssueReapplyNoLayout, layout, win.Name)
+		}
+		return
+	}
+	if slices.Contains(builtinLayouts, win.Layout) {
+		return
+	}
+	cells, ok := layoutStringCells(win.Layout)
+	if !ok {
+		v.report(fieldNode(node, keyLayout), IssueWindowLayout, layout, win.Name, win.Layout)
+		return
+	}
+	if panes := max(len(win.Leaves()), 1); cells != panes {
+		v.report(fieldNode(node, keyLayout), IssueLayoutPanes, layout, win.Name, cells, panes)
+	}
+}
+
+// layoutStringCells returns how many panes a tmux layout string lays out,
+// or false when its checksum doesn't match.
+func layoutStringCells(s string) (int, bool) {
+	if len(s) <= layoutChecksumLen || s[layoutChecksumLen] != ',' {
+		return 0, false
+	}
+	want, err := strconv.ParseUint(s[:layoutChecksumLen], 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	body := s[layoutChecksumLen+1:]
+	var sum uint16
+	for i := 0; i < len(body); i++ {
+		sum = sum>>1 + (sum&1)<<15
+		sum += uint16(body[i])
+	}
+	if uint64(sum) != want {
+		return 0, false
+	}
+	return len(layoutCell.FindAllString(body, -1)), true
+}
+
+func (v *validator) checkPane(layout, window string, pane Pane, node *yaml.Node) {
+	v.checkSplit(layout, window, pane.Split, node)
+	v.checkSize(layout, window, pane.Size, node)
+}
+
+// checkTree checks a node of a window's pane tree and everything below it.
+// leaf counts the tree's panes before n, numbering them as in a panes list.
+func (v *validator) checkTree(layout, window string, n PaneNode, node *yaml.Node, leaf *int) {
+	v.checkSplit(layout, window, n.Split, node)
+	v.checkSize(layout, window, n.Size, node)
+
+	if len(n.Children) == 0 {
+		if n.Split != "" {
+			v.report(fieldNode(node, keySplit), IssueTreeLeafSplit, layout, window, n.Split)
+		}
+		v.checkEnv(fmt.Sprintf(IssueFmtPane, layout, window, *leaf), n.Env, mappingValue(node, keyEnv))
+		*leaf++
+		return
+	}
+
+	if n.Command != "" || n.Root != "" || len(n.Env) > 0 {
+		v.report(node, IssueTreeNodePane, layout, window)
+	}
+	total := 0
+	children := mappingValue(node, keyChildren)
+	for i, child := range n.Children {
+		if m := sizePattern.FindStringSubmatch(child.Size); m != nil {
+			pct, _ := strconv.Atoi(m[1])
+			total += pct
+		}
+		v.checkTree(layout, window, child, sequenceItem(children, i), leaf)
+	}
+	if total > MaxSizePercent {
+		v.report(fieldNode(node, keyChildren), IssueTreeSizes, layout, window, total, MaxSizePercent)
+	}
+}
+
+func (v *validator) checkSplit(layout, window, split string, node *yaml.Node) {
+	switch split {
+	case "", SplitHorizontal, SplitVertical:
+	default:
+		v.report(fieldNode(node, keySplit), IssueInvalidSplit, layout, window, split, SplitHorizontal, SplitVertical)
+	}
+}
+
+func (v *validator) checkSize(layout, window, size string, node *yaml.Node) {
+	if size == "" {
+		return
+	}
+	m := sizePattern.FindStringSubmatch(size)
+	if m == nil {
+		v.report(fieldNode(node, keySize), IssueMalformedSize, layout, window, size)
+		return
+	}
+	pct, err := strconv.Atoi(m[1])
+	if err != nil || pct < MinSizePercent || pct > MaxSizePercent {
+		v.report(fieldNode(node, keySize), IssueSizeOutOfRange, layout, window, size, MinSizePercent, MaxSizePercent)
+	}
+}
+
+func layoutHasWindow(layout Layout, name string) bool {
+	for _, w := range layout.Windows {
+		if w.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// documentRoot unwraps a document node to its top-level content node.
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mappingKey returns the key node for key in a mapping node, falling back to
+// the mapping itself.
+func mappingKey(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return n
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i]
+		}
+	}
+	return n
+}
+
+// fieldNode returns the value node for key, falling back to the mapping itself
+// so issues about absent fields still point at the enclosing entry.
+func fieldNode(n *yaml.Node, key string) *yaml.Node {
+	if v := mappingValue(n, key); v != nil {
+		return v
+	}
+	return n
+}
+
+// sequenceItem returns the i-th item of a sequence node, or nil.
+func sequenceItem(n *yaml.Node, i int) *yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode || i >= len(n.Content) {
+		return nil
+	}
+	return n.Content[i]
+}
+
+func lineOf(n *yaml.Node) int {
+	if n == nil {
+		return 0
+	}
+	return n.Line
+}
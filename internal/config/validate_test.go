@@ -0,0 +1,395 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidate(t *testing.T) {
+	projDir := t.TempDir()
+	filePath := filepath.Join(projDir, "file.txt")
+	if err := os.WriteFile(filePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string // "line: message substring" per expected issue, in order
+	}{
+		{
+			name: "valid config has no issues",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    layout: dev
+    on_start:
+      - window: editor
+        command: nvim .
+layouts:
+  dev:
+    windows:
+      - name: editor
+        panes:
+          - size: "70%"
+          - split: horizontal
+            size: "30%"
+`,
+		},
+		{
+			name: "duplicate project names",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+  - name: api
+    path: ` + projDir + `
+`,
+			want: []string{`5: duplicate project name "api" (first defined at line 3)`},
+		},
+		{
+			name: "unknown layout",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    layout: nope
+`,
+			want: []string{`5: project "api": unknown layout "nope"`},
+		},
+		{
+			name: "on_start window missing from layout",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    on_start:
+      - window: server
+        command: make run
+`,
+			want: []string{`6: project "api": on_start window "server" is not in layout "(default)"`},
+		},
+		{
+			name: "missing roots",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    layout: mono
+layouts:
+  mono:
+    windows:
+      - name: server
+        root: services/api
+        panes:
+          - root: .
+          - root: ` + filePath + `
+`,
+			want: []string{
+				`5: project "api": root "services/api" of window "server" is not a directory`,
+				`5: project "api": root "` + filePath + `" of window "server" is not a directory`,
+			},
+		},
+		{
+			name: "bad env names and files",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    layout: dev
+    env:
+      GOOD: "1"
+      BAD-NAME: "1"
+    env_file:
+      - missing.env
+layouts:
+  dev:
+    windows:
+      - name: main
+        env:
+          "2FAST": "1"
+`,
+			want: []string{
+				`8: project "api": invalid environment variable name "BAD-NAME"`,
+				`10: project "api": env_file "missing.env"`,
+				`16: layout "dev", window "main": invalid environment variable name "2FAST"`,
+			},
+		},
+		{
+			name: "bad wait_for conditions",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    on_start:
+      - window: main
+        command: postgres
+        wait_for:
+          port: db:99999
+          timeout: forever
+      - window: main
+        command: make migrate
+        wait_for:
+          output: "ready ("
+          delay: 2s
+`,
+			want: []string{
+				`9: project "api": on_start wait_for port "db:99999" is not a port or host:port`,
+				`10: project "api": on_start wait_for timeout "forever" is not a positive duration`,
+				`14: project "api": on_start wait_for in window "main" must set exactly one of port, file, output or delay`,
+				`14: project "api": on_start wait_for output "ready (" is not a valid regular expression`,
+			},
+		},
+		{
+			name: "bad on_stop steps",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    on_stop:
+      - window: main
+        keys: C-c
+        run: make down
+      - keys: C-c
+      - window: server
+        wait: 10s
+      - window: main
+        wait: soon
+      - run: docker compose down
+`,
+			want: []string{
+				`6: project "api": on_stop step 1 must set exactly one of keys, wait or run`,
+				`9: project "api": on_stop step 2 sends keys or waits but names no window`,
+				`10: project "api": on_stop window "server" is not in layout "(default)"`,
+				`13: project "api": on_stop wait "soon" is not a positive duration`,
+			},
+		},
+		{
+			name: "bad path",
+			content: `
+projects:
+  - name: missing
+    path: /nonexistent/tplm/path
+  - name: file
+    path: ` + filePath + `
+  - name: empty
+`,
+			want: []string{
+				`4: project "missing": path "/nonexistent/tplm/path" does not exist`,
+				`6: project "file": path`,
+				`7: project "empty": path is empty`,
+			},
+		},
+		{
+			name: "bad windows and panes",
+			content: `
+layouts:
+  dev:
+    windows:
+      - panes:
+          - size: "70"
+          - split: diagonal
+            size: "130%"
+`,
+			want: []string{
+				`5: layout "dev": window 0 has an empty name`,
+				`6: malformed size "70"`,
+				`7: invalid split "diagonal"`,
+				`8: size "130%" is outside 1%-100%`,
+			},
+		},
+		{
+			name: "bad pane trees",
+			content: `
+layouts:
+  dev:
+    windows:
+      - name: grid
+        panes:
+          - command: nvim
+        tree:
+          split: horizontal
+          command: nvim
+          children:
+            - size: "70%"
+              split: vertical
+            - size: "50%"
+              env:
+                BAD-NAME: x
+`,
+			want: []string{
+				`8: layout "dev", window "grid": set panes or tree, not both`,
+				`9: tree node with children can't set command, root or env`,
+				`12: tree children sizes add up to 120%, more than 100%`,
+				`13: tree node splits "vertical" but has no children`,
+				`16: layout "dev", window "grid", pane 1: invalid environment variable name "BAD-NAME"`,
+			},
+		},
+		{
+			name: "bad window layouts",
+			content: `
+layouts:
+  dev:
+    windows:
+      - name: tiled
+        layout: tiled
+        panes: [{}, {}, {}, {}]
+      - name: copied
+        layout: "d67e,80x24,0,0{40x24,0,0,0,39x24,41,0[39x12,41,0,1,39x11,41,13,2]}"
+        panes: [{}, {}, {}]
+      - name: short
+        layout: "d67e,80x24,0,0{40x24,0,0,0,39x24,41,0[39x12,41,0,1,39x11,41,13,2]}"
+        panes: [{}, {}]
+      - name: edited
+        layout: "d67e,80x24,0,0{40x24,0,0,0,39x24,41,0[39x12,41,0,1,39x11,41,12,2]}"
+      - name: typo
+        layout: tilde
+        reapply_layout: true
+      - name: none
+        reapply_layout: true
+`,
+			want: []string{
+				`12: layout "dev", window "short": layout string has 3 panes, window has 2`,
+				`15: window "edited": layout "d67e,`,
+				`17: window "typo": layout "tilde" is neither a tmux layout name nor a valid layout string`,
+				`20: window "none": reapply_layout is set but layout is empty`,
+			},
+		},
+		{
+			name: "undefined template variable",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    vars:
+      service: api
+    on_start:
+      - window: main
+        command: go run ./cmd/{{.Vars.servcie}}
+`,
+			want: []string{
+				`3: project "api": expanding "go run ./cmd/{{.Vars.servcie}}"`,
+			},
+		},
+		{
+			name: "bad layout composition",
+			content: `
+projects:
+  - name: api
+    path: ` + projDir + `
+    layout: c
+    on_start:
+      - window: main
+        command: nvim .
+windows:
+  editor:
+    use: git
+    panes:
+      - size: "7"
+layouts:
+  a:
+    extends: b
+  b:
+    extends: a
+  c:
+    extends: nope
+    windows:
+      - use: edtior
+      - use: editor
+        name: main
+`,
+			want: []string{
+				`11: window "editor": window definitions can't use others`,
+				`13: layout "(windows)", window "editor": malformed size "7"`,
+				`16: layout "a": extends cycle`,
+				`18: layout "b": extends cycle`,
+				`20: layout "c" extends unknown layout "nope"`,
+				`22: layout "c" uses unknown window "edtior"`,
+			},
+		},
+		{
+			name: "discover settings",
+			content: `
+discover:
+  roots:
+    - ` + projDir + `
+    - /nonexistent/tplm/code
+  max_depth: -1
+  layout: dve
+  marker_layouts:
+    go.mod: go
+  cache_ttl: soon
+layouts:
+  go:
+    windows:
+      - name: editor
+`,
+			want: []string{
+				`5: discover: root "/nonexistent/tplm/code" is not a directory`,
+				`6: discover: max_depth -1 is negative`,
+				`7: discover: unknown layout "dve"`,
+				`10: discover: cache_ttl "soon" is not a duration`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.content)
+			issues, err := Validate(path)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if len(issues) != len(tt.want) {
+				t.Fatalf("Validate() = %v, want %d issues", issues, len(tt.want))
+			}
+			for i, want := range tt.want {
+				line, msg, _ := strings.Cut(want, ": ")
+				got := issues[i]
+				if got.File != path {
+					t.Errorf("issue %d file = %q, want %q", i, got.File, path)
+				}
+				if wantLine, _ := strconv.Atoi(line); got.Line != wantLine {
+					t.Errorf("issue %d line = %d, want %d (%s)", i, got.Line, wantLine, got.Message)
+				}
+				if !strings.Contains(got.Message, msg) {
+					t.Errorf("issue %d message = %q, want substring %q", i, got.Message, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	t.Run("file not found", func(t *testing.T) {
+		if _, err := Validate("/nonexistent/path/config.yaml"); err == nil {
+			t.Error("Validate() expected error for missing file, got nil")
+		}
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		if _, err := Validate(writeConfig(t, "{{invalid yaml")); err == nil {
+			t.Error("Validate() expected error for invalid YAML, got nil")
+		}
+	})
+}
+
+func TestIssueString(t *testing.T) {
+	issue := Issue{File: "config.yaml", Line: 3, Column: 5, Message: "boom"}
+	if got, want := issue.String(), "config.yaml:3:5: boom"; got != want {
+		t.Errorf("Issue.String() = %q, want %q", got, want)
+	}
+}
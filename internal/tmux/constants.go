@@ -1,55 +1,83 @@
 package tmux
 
+import "time"
+
 // tmux binary.
 const TmuxBin = "tmux"
 
+// EnvTmux is set by tmux in every pane; its presence means we run inside a client.
+const EnvTmux = "TMUX"
+
 // Subcommand names.
 const (
 	CmdNewSession     = "new-session"
 	CmdKillSession    = "kill-session"
 	CmdRenameSession  = "rename-session"
 	CmdSwitchClient   = "switch-client"
+	CmdAttachSession  = "attach-session"
 	CmdNewWindow      = "new-window"
 	CmdKillWindow     = "kill-window"
 	CmdRenameWindow   = "rename-window"
 	CmdSendKeys       = "send-keys"
 	CmdSelectWindow   = "select-window"
 	CmdSelectPane     = "select-pane"
+	CmdSelectLayout   = "select-layout"
 	CmdSplitWindow    = "split-window"
 	CmdListSessions   = "list-sessions"
 	CmdListWindows    = "list-windows"
 	CmdDisplayMessage = "display-message"
 	CmdHasSession     = "has-session"
+	CmdCapturePane    = "capture-pane"
+	CmdListPanes      = "list-panes"
+	CmdSetEnvironment = "set-environment"
+	CmdRespawnPane    = "respawn-pane"
 )
 
 // Flags.
 const (
-	FlagDetached = "-d"
-	FlagSession  = "-s"
-	FlagDir      = "-c"
-	FlagTarget   = "-t"
-	FlagName     = "-n"
-	FlagFormat   = "-F"
-	FlagPrint    = "-p"
-	FlagVertical = "-v"
-	FlagHoriz    = "-h"
+	FlagSocketName = "-L"
+	FlagSocketPath = "-S"
+	FlagDetached   = "-d"
+	FlagSession    = "-s"
+	FlagDir        = "-c"
+	FlagTarget     = "-t"
+	FlagName       = "-n"
+	FlagFormat     = "-F"
+	FlagPrint      = "-p"
+	FlagPrintInfo  = "-P"
+	FlagVertical   = "-v"
+	FlagHoriz      = "-h"
+	FlagEscapes    = "-e"
+	FlagStart      = "-S"
+	FlagJoin       = "-J"
+	FlagEnv        = "-e"
+	FlagKill       = "-k"
 )
 
+// EnvSep separates a variable's name from its value in "NAME=value" pairs.
+const EnvSep = "="
+
+// HistoryStart makes capture-pane -S start at the top of the pane's history.
+const HistoryStart = "-"
+
 // Format strings for tmux queries.
 const (
 	SessionListFormat = "#{session_name}\t#{session_windows}\t#{session_attached}\t#{session_path}"
 	WindowListFormat  = "#{window_index}\t#{window_name}\t#{window_active}"
 	SessionNameFormat = "#{session_name}"
+	SessionPathFormat = "#{session_path}"
+	PaneListFormat    = "#{pane_id}\t#{pane_index}\t#{pane_active}\t#{pane_left}\t#{pane_top}\t#{pane_width}\t#{pane_height}\t#{pane_current_command}\t#{pane_current_path}"
+	// IDs printed by commands that create windows and panes (-P -F).
+	WindowPaneIDFormat = "#{window_id}\t#{pane_id}"
+	PaneIDFormat       = "#{pane_id}"
 )
 
 // Target format strings used to build tmux target specifiers.
-const (
-	FmtSessionWindow     = "%s:%d"   // session:window
-	FmtTargetPane0       = "%s.0"    // target.pane0
-	FmtTargetPaneN       = "%s.%d"   // target.paneN
-	FmtSessionWindowPane = "%s:%d.0" // session:window.pane0
-	FmtSessionFirst      = "%s:0"    // session:firstWindow
-)
+const FmtSessionWindow = "%s:%d" // session:windowIndex, with the index as reported by tmux
+
+// RestartPrefix renames a session kept alive while it is rebuilt under its own
+// name. A suffix would make tmux match the old session by prefix.
+const RestartPrefix = "restarting-"
 
 // Error substrings used to detect expected failure modes.
 const (
@@ -59,19 +87,49 @@ const (
 
 // Error message templates.
 const (
-	ErrFmtRun              = "tmux %s: %s (%w)"
-	ErrFmtRenameWindow     = "renaming window %q: %w"
-	ErrFmtCreateWindow     = "creating window %q: %w"
-	ErrFmtSetDir           = "setting directory for window %q: %w"
-	ErrFmtRunPaneCmd       = "running command in pane %d of window %q: %w"
-	ErrFmtSplitPane        = "splitting pane %d in window %q: %w"
-	ErrFmtRunOnStart       = "running on_start for window %q: %w"
-	ErrFmtParseWinCount    = "parsing window count for session %q: %w"
-	ErrFmtParseWinIndex    = "parsing window index %q: %w"
+	ErrFmtRun           = "tmux %s: %s (%w)"
+	ErrFmtRenameWindow  = "renaming window %q: %w"
+	ErrFmtCreateWindow  = "creating window %q: %w"
+	ErrFmtRunPaneCmd    = "running command in pane %d of window %q: %w"
+	ErrFmtSplitPane     = "splitting pane %d in window %q: %w"
+	ErrFmtSelectLayout  = "selecting layout of window %q: %w"
+	ErrFmtRunOnStart    = "running on_start for window %q: %w"
+	ErrFmtParseWinCount = "parsing window count for session %q: %w"
+	ErrFmtParseWinIndex = "parsing window index %q: %w"
+	ErrFmtParseIDs      = "parsing window and pane IDs from %q"
+	ErrFmtParsePane     = "parsing pane %q: %w"
+	ErrFmtReadWindow    = "reading window %q: %w"
+	ErrFmtKillWindow    = "killing window %q: %w"
+	ErrFmtRunOnStop     = "running on_stop step %d: %w"
+	ErrFmtParseWait     = "parsing wait %q: %w"
+	ErrFmtShellCommand  = "%s: %s (%w)"
+	ErrFmtBeforeStart   = "running before_start: %w"
+	ErrFmtSetEnv        = "setting %s in the session environment: %w"
+	ErrFmtRespawnPane   = "setting directory and environment of window %q: %w"
+	ErrFmtWaitFor       = "on_start for window %q: %w"
+	ErrFmtWaitTimeout   = "timed out after %s waiting for %s"
+	ErrFmtWaitInvalid   = "invalid wait_for: %w"
+	ErrWaitNoCondition  = "wait_for sets none of port, file, output or delay"
+	FmtWaitPort         = "port %s"
+	FmtWaitFile         = "file %s"
+	FmtWaitOutput       = "output matching %q"
 )
 
-// Shell command templates.
-const FmtCdCommand = "cd %s"
+// Shell that runs before_start and on_stop commands.
+const (
+	Shell            = "sh"
+	ShellCommandFlag = "-c"
+)
+
+// PollInterval is how long to sleep between checks while waiting for panes
+// to exit or an on_start wait_for to be met.
+const PollInterval = 200 * time.Millisecond
+
+// DefaultPortHost is dialed by a wait_for port that names no host.
+const DefaultPortHost = "localhost"
+
+// Size format written for pane sizes in snapshots.
+const FmtSizePercent = "%d%%"
 
 // Size suffix stripped when passing percentage to tmux.
 const SizeSuffix = "%"
@@ -80,6 +138,7 @@ const SizeSuffix = "%"
 const (
 	SessionFieldCount = 4
 	WindowFieldCount  = 3
+	PaneFieldCount    = 9
 	AttachedValue     = "1"
 	ActiveValue       = "1"
 )
@@ -88,4 +147,7 @@ const (
 const KeyEnter = "Enter"
 
 // Split direction values.
-const SplitVertical = "vertical"
+const (
+	SplitVertical   = "vertical"
+	SplitHorizontal = "horizontal"
+)
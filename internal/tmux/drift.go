@@ -0,0 +1,106 @@
+package tmux
+
+import (
+	"fmt"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+// WindowDiff compares a layout window with the live window matched to it.
+// Name is empty for a live window the layout doesn't have, and LiveName is
+// empty for a layout window the session lacks.
+type WindowDiff struct {
+	Name      string
+	LiveName  string
+	LiveIndex int // tmux index of the live window, unless Missing
+	WantPanes int
+	GotPanes  int
+}
+
+// Missing reports whether the session lacks the layout window.
+func (w WindowDiff) Missing() bool { return w.LiveName == "" }
+
+// Extra reports whether the live window is not in the layout.
+func (w WindowDiff) Extra() bool { return w.Name == "" }
+
+// Renamed reports whether the layout window lives on under another name.
+func (w WindowDiff) Renamed() bool {
+	return !w.Missing() && !w.Extra() && w.Name != w.LiveName
+}
+
+// PanesDiffer reports whether a matched window has a different pane count.
+func (w WindowDiff) PanesDiffer() bool {
+	return !w.Missing() && !w.Extra() && w.WantPanes != w.GotPanes
+}
+
+// InSync reports whether the live window matches the layout window.
+func (w WindowDiff) InSync() bool {
+	return !w.Missing() && !w.Extra() && !w.Renamed() && !w.PanesDiffer()
+}
+
+// Drift is how a live session differs from its layout: one entry per layout
+// window in layout order, followed by the extra live windows.
+type Drift struct {
+	Windows []WindowDiff
+}
+
+// InSync reports whether the session matches its layout.
+func (d Drift) InSync() bool {
+	for _, w := range d.Windows {
+		if !w.InSync() {
+			return false
+		}
+	}
+	return true
+}
+
+// DiffSession compares a live session with the layout it was built from.
+// Windows are matched by name first; a layout window and a live window left
+// unmatched at the same position are taken to be one window renamed.
+func (c *Client) DiffSession(session string, layout config.Layout) (Drift, error) {
+	windows, err := c.ListWindows(session)
+	if err != nil {
+		return Drift{}, err
+	}
+	panes := make([]int, len(windows))
+	for i, w := range windows {
+		list, err := c.ListPanes(fmt.Sprintf(FmtSessionWindow, session, w.Index))
+		if err != nil {
+			return Drift{}, fmt.Errorf(ErrFmtReadWindow, w.Name, err)
+		}
+		panes[i] = len(list)
+	}
+
+	// match[i] is the live window matched to layout window i, or -1.
+	match := make([]int, len(layout.Windows))
+	used := make([]bool, len(windows))
+	for i, win := range layout.Windows {
+		match[i] = -1
+		for j, w := range windows {
+			if !used[j] && w.Name == win.Name {
+				match[i], used[j] = j, true
+				break
+			}
+		}
+	}
+	for i := range layout.Windows {
+		if match[i] < 0 && i < len(windows) && !used[i] {
+			match[i], used[i] = i, true
+		}
+	}
+
+	var d Drift
+	for i, win := range layout.Windows {
+		diff := WindowDiff{Name: win.Name, WantPanes: max(len(win.Leaves()), 1)}
+		if j := match[i]; j >= 0 {
+			diff.LiveName, diff.LiveIndex, diff.GotPanes = windows[j].Name, windows[j].Index, panes[j]
+		}
+		d.Windows = append(d.Windows, diff)
+	}
+	for j, w := range windows {
+		if !used[j] {
+			d.Windows = append(d.Windows, WindowDiff{LiveName: w.Name, LiveIndex: w.Index, GotPanes: panes[j]})
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,81 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+func TestDiffSession(t *testing.T) {
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Panes: []config.Pane{{}, {Split: "horizontal"}}},
+		{Name: "server"},
+		{Name: "logs"},
+		{Name: "db"},
+	}}
+
+	fake := tmuxtest.New()
+	// editor lost a pane, server was renamed to api, logs is gone, and
+	// scratch was added. db sits at position 3, which scratch takes, but is
+	// matched by name instead.
+	fake.AddSession("proj", "/src", "editor", "api", "db", "scratch")
+
+	drift, err := NewClient(fake).DiffSession("proj", layout)
+	if err != nil {
+		t.Fatalf("DiffSession() error = %v", err)
+	}
+	want := []WindowDiff{
+		{Name: "editor", LiveName: "editor", WantPanes: 2, GotPanes: 1},
+		{Name: "server", LiveName: "api", LiveIndex: 1, WantPanes: 1, GotPanes: 1},
+		{Name: "logs", WantPanes: 1},
+		{Name: "db", LiveName: "db", LiveIndex: 2, WantPanes: 1, GotPanes: 1},
+		{LiveName: "scratch", LiveIndex: 3, GotPanes: 1},
+	}
+	if !reflect.DeepEqual(drift.Windows, want) {
+		t.Fatalf("DiffSession() =\n%+v\nwant\n%+v", drift.Windows, want)
+	}
+
+	checks := []struct {
+		name string
+		got  []bool
+		want []bool
+	}{
+		{"Missing", collect(drift, WindowDiff.Missing), []bool{false, false, true, false, false}},
+		{"Extra", collect(drift, WindowDiff.Extra), []bool{false, false, false, false, true}},
+		{"Renamed", collect(drift, WindowDiff.Renamed), []bool{false, true, false, false, false}},
+		{"PanesDiffer", collect(drift, WindowDiff.PanesDiffer), []bool{true, false, false, false, false}},
+		{"InSync", collect(drift, WindowDiff.InSync), []bool{false, false, false, true, false}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if drift.InSync() {
+		t.Error("InSync() = true for a drifted session")
+	}
+}
+
+func TestDiffSessionInSync(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("proj", "/src", "editor", "server")
+
+	layout := config.Layout{Windows: []config.Window{{Name: "editor"}, {Name: "server"}}}
+	drift, err := NewClient(fake).DiffSession("proj", layout)
+	if err != nil {
+		t.Fatalf("DiffSession() error = %v", err)
+	}
+	if !drift.InSync() {
+		t.Errorf("InSync() = false for %+v", drift.Windows)
+	}
+}
+
+func collect(d Drift, f func(WindowDiff) bool) []bool {
+	out := make([]bool, len(d.Windows))
+	for i, w := range d.Windows {
+		out[i] = f(w)
+	}
+	return out
+}
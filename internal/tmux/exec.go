@@ -3,13 +3,39 @@ package tmux
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"syscall"
 )
 
+// Runner executes a single tmux command and returns its stdout.
+type Runner interface {
+	Run(args ...string) (string, error)
+}
+
+// ExecRunner runs commands with the tmux binary on $PATH. When SocketName
+// (tmux -L) or SocketPath (tmux -S) is set, commands target that server
+// instead of the default one; SocketPath wins if both are set.
+type ExecRunner struct {
+	SocketName string
+	SocketPath string
+}
+
+// SocketRunner returns an ExecRunner for a socket given as in the config's
+// tmux_socket: a value containing a path separator is a socket path, anything
+// else a socket name. An empty socket selects the default server.
+func SocketRunner(socket string) ExecRunner {
+	if strings.ContainsRune(socket, filepath.Separator) {
+		return ExecRunner{SocketPath: socket}
+	}
+	return ExecRunner{SocketName: socket}
+}
+
 // Run executes a tmux command and returns its stdout.
-func Run(args ...string) (string, error) {
-	cmd := exec.Command(TmuxBin, args...)
+func (r ExecRunner) Run(args ...string) (string, error) {
+	cmd := exec.Command(TmuxBin, r.globalArgs(args)...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -20,8 +46,57 @@ func Run(args ...string) (string, error) {
 	return strings.TrimRight(stdout.String(), "\n"), nil
 }
 
+// Exec replaces the current process with tmux. It is used for commands such as
+// attach-session that take over the terminal, and only returns on failure.
+func (r ExecRunner) Exec(args ...string) error {
+	bin, err := exec.LookPath(TmuxBin)
+	if err != nil {
+		return err
+	}
+	argv := append([]string{TmuxBin}, r.globalArgs(args)...)
+	return syscall.Exec(bin, argv, os.Environ())
+}
+
+// globalArgs prepends the server selection flags to args.
+func (r ExecRunner) globalArgs(args []string) []string {
+	switch {
+	case r.SocketPath != "":
+		return append([]string{FlagSocketPath, r.SocketPath}, args...)
+	case r.SocketName != "":
+		return append([]string{FlagSocketName, r.SocketName}, args...)
+	}
+	return args
+}
+
+// execer is implemented by runners that can hand the terminal over to tmux.
+type execer interface {
+	Exec(args ...string) error
+}
+
+// InsideTmux reports whether tplm runs inside a tmux client, where sessions
+// are switched to rather than attached.
+func InsideTmux() bool {
+	return os.Getenv(EnvTmux) != ""
+}
+
+// Client issues tmux commands through a Runner. All session, window and
+// layout operations are methods on Client so callers can inject a fake.
+type Client struct {
+	runner Runner
+}
+
+// NewClient returns a client that executes commands through r.
+func NewClient(r Runner) *Client {
+	return &Client{runner: r}
+}
+
+// Run executes a tmux command and returns its stdout.
+func (c *Client) Run(args ...string) (string, error) {
+	return c.runner.Run(args...)
+}
+
 // RunSilent executes a tmux command without capturing output.
-func RunSilent(args ...string) error {
-	_, err := Run(args...)
+func (c *Client) RunSilent(args ...string) error {
+	_, err := c.Run(args...)
 	return err
 }
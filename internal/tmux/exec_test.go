@@ -0,0 +1,27 @@
+package tmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSocketRunner(t *testing.T) {
+	tests := []struct {
+		name   string
+		socket string
+		want   string
+	}{
+		{name: "default server", socket: "", want: "list-sessions"},
+		{name: "socket name", socket: "work", want: "-L work list-sessions"},
+		{name: "socket path", socket: "/tmp/tmux-1000/personal", want: "-S /tmp/tmux-1000/personal list-sessions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(SocketRunner(tt.socket).globalArgs([]string{CmdListSessions}), " ")
+			if got != tt.want {
+				t.Errorf("globalArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+// startIntegration starts a private tmux server and returns a client bound to
+// it together with a resolved project directory.
+func startIntegration(t *testing.T, options ...string) (*tmuxtest.Server, *Client, string) {
+	t.Helper()
+	srv := tmuxtest.StartServer(t, options...)
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, NewClient(ExecRunner{SocketName: srv.SocketName}), dir
+}
+
+func TestIntegrationApplyLayout(t *testing.T) {
+	srv, c, dir := startIntegration(t)
+
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Panes: []config.Pane{
+			{Size: "60%"},
+			{Split: "horizontal", Size: "40%"},
+			{Split: "vertical", Size: "50%"},
+		}},
+		{Name: "server"},
+	}}
+	if err := c.NewSession("api", dir); err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	if _, err := c.ApplyLayout("api", layout, dir); err != nil {
+		t.Fatalf("ApplyLayout() error = %v", err)
+	}
+
+	panes := srv.Panes("api")
+	if len(panes) != 4 {
+		t.Fatalf("got %d panes, want 4: %+v", len(panes), panes)
+	}
+	editor, right, bottom, server := panes[0], panes[1], panes[2], panes[3]
+
+	for _, p := range panes[:3] {
+		if p.WindowName != "editor" {
+			t.Errorf("pane %d in window %q, want editor", p.Index, p.WindowName)
+		}
+		if p.Path != dir {
+			t.Errorf("pane %d path = %q, want %q", p.Index, p.Path, dir)
+		}
+	}
+	if server.WindowName != "server" {
+		t.Errorf("last pane in window %q, want server", server.WindowName)
+	}
+	if right.Left <= editor.Left || right.Top != editor.Top {
+		t.Errorf("pane 1 not split horizontally from pane 0: %+v vs %+v", right, editor)
+	}
+	if bottom.Top <= right.Top || bottom.Left != right.Left {
+		t.Errorf("pane 2 not split vertically from pane 1: %+v vs %+v", bottom, right)
+	}
+}
+
+func TestIntegrationBaseIndex(t *testing.T) {
+	srv, c, dir := startIntegration(t, "base-index 1", "pane-base-index 1")
+
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Panes: []config.Pane{{}, {Split: "horizontal", Size: "30%"}}},
+		{Name: "server", Panes: []config.Pane{{}, {Split: "vertical", Command: "touch pane-cmd"}}},
+	}}
+	if err := c.NewSession("api", dir); err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	windows, err := c.ApplyLayout("api", layout, dir)
+	if err != nil {
+		t.Fatalf("ApplyLayout() error = %v", err)
+	}
+	onStart := []config.OnStart{{Window: "server", Command: "touch on-start"}}
+	if err := c.RunOnStart(windows, onStart, ""); err != nil {
+		t.Fatalf("RunOnStart() error = %v", err)
+	}
+
+	panes := srv.Panes("api")
+	var names []string
+	for _, p := range panes {
+		names = append(names, fmt.Sprintf("%s.%d", p.WindowName, p.Index))
+	}
+	if got, want := strings.Join(names, " "), "editor.1 editor.2 server.1 server.2"; got != want {
+		t.Errorf("panes = %s, want %s", got, want)
+	}
+
+	// Both commands run in the server window, whose shells start in dir.
+	for _, file := range []string{"pane-cmd", "on-start"} {
+		waitForFile(t, filepath.Join(dir, file))
+	}
+}
+
+func TestIntegrationSnapshotRoundTrip(t *testing.T) {
+	srv, c, dir := startIntegration(t)
+
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Panes: []config.Pane{
+			{},
+			{Split: "horizontal", Size: "35%"},
+			{Split: "vertical", Size: "40%"},
+		}},
+		{Name: "server"},
+	}}
+	if err := c.NewSession("api", dir); err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	if _, err := c.ApplyLayout("api", layout, dir); err != nil {
+		t.Fatalf("ApplyLayout() error = %v", err)
+	}
+
+	snap, err := c.SnapshotSession("api")
+	if err != nil {
+		t.Fatalf("SnapshotSession() error = %v", err)
+	}
+	if snap.Path != dir {
+		t.Errorf("snapshot path = %q, want %q", snap.Path, dir)
+	}
+
+	// A session built from the snapshot has the same panes in the same places.
+	if err := c.NewSession("copy", snap.Path); err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	if _, err := c.ApplyLayout("copy", snap.Layout, snap.Path); err != nil {
+		t.Fatalf("ApplyLayout(snapshot) error = %v", err)
+	}
+	want, got := srv.Panes("api"), srv.Panes("copy")
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("panes from snapshot = %+v, want %+v (snapshot %+v)", got, want, snap.Layout)
+	}
+}
+
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not created", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestIntegrationSessions(t *testing.T) {
+	_, c, dir := startIntegration(t)
+
+	for _, name := range []string{"api", "web"} {
+		if err := c.NewSession(name, dir); err != nil {
+			t.Fatalf("NewSession(%q) error = %v", name, err)
+		}
+	}
+	if _, err := c.NewWindow("api", "server", dir); err != nil {
+		t.Fatalf("NewWindow() error = %v", err)
+	}
+
+	sessions, err := c.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Name != "api" || sessions[0].Windows != 2 || sessions[0].Path != dir {
+		t.Fatalf("ListSessions() = %+v, want api (2 windows at %s) and web", sessions, dir)
+	}
+
+	windows, err := c.ListWindows("api")
+	if err != nil {
+		t.Fatalf("ListWindows() error = %v", err)
+	}
+	if len(windows) != 2 || windows[1].Name != "server" || !windows[1].Active {
+		t.Fatalf("ListWindows() = %+v, want active server window second", windows)
+	}
+
+	if err := c.KillWindow(fmt.Sprintf(FmtSessionWindow, "api", windows[1].Index)); err != nil {
+		t.Fatalf("KillWindow() error = %v", err)
+	}
+	if err := c.RenameSession("web", "frontend"); err != nil {
+		t.Fatalf("RenameSession() error = %v", err)
+	}
+	if c.SessionExists("web") || !c.SessionExists("frontend") {
+		t.Error("rename did not take effect")
+	}
+	if err := c.KillSession("frontend"); err != nil {
+		t.Fatalf("KillSession() error = %v", err)
+	}
+
+	sessions, err = c.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Name != "api" || sessions[0].Windows != 1 {
+		t.Errorf("ListSessions() = %+v, want only api with 1 window", sessions)
+	}
+}
@@ -1,104 +1,268 @@
 package tmux
 
 import (
+	"cmp"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/rmvaldesd/tplm/internal/config"
 )
 
+// WindowRef identifies a window built by ApplyLayout by its tmux IDs, which
+// stay valid whatever base-index and pane-base-index are set to.
+type WindowRef struct {
+	Name  string
+	ID    string   // window ID, e.g. "@3"
+	Panes []string // pane IDs in layout order, e.g. "%7"
+
+	// Layout is the tmux layout RunOnStart selects again once the window's
+	// on_start commands ran, if the window asks for it.
+	Layout string
+}
+
 // ApplyLayout creates windows and splits panes according to the layout config.
-func ApplyLayout(sessionName string, layout config.Layout, projectPath string) error {
+// It returns the IDs of the windows and panes it built, in layout order.
+func (c *Client) ApplyLayout(sessionName string, layout config.Layout, projectPath string) ([]WindowRef, error) {
+	refs := make([]WindowRef, 0, len(layout.Windows))
 	for i, win := range layout.Windows {
-		target := fmt.Sprintf(FmtSessionWindow, sessionName, i)
+		var ref WindowRef
+		var err error
 
 		if i == 0 {
 			// First window is created with the session; just rename it.
-			if err := RenameWindow(target, win.Name); err != nil {
-				return fmt.Errorf(ErrFmtRenameWindow, win.Name, err)
+			ref, err = c.activeWindow(sessionName)
+			if err == nil {
+				err = c.RenameWindow(ref.ID, win.Name)
 			}
-		} else {
-			if err := NewWindow(sessionName, win.Name); err != nil {
-				return fmt.Errorf(ErrFmtCreateWindow, win.Name, err)
+			if err != nil {
+				return nil, fmt.Errorf(ErrFmtRenameWindow, win.Name, err)
 			}
-			// Set the working directory for the new window.
-			if err := SendKeys(target, fmt.Sprintf(FmtCdCommand, shellEscape(projectPath))); err != nil {
-				return fmt.Errorf(ErrFmtSetDir, win.Name, err)
+			// Its shell started in the project path before the window's root
+			// and env were known.
+			dir, env := paneDir(win, 0, projectPath), paneEnv(win, 0)
+			if dir != projectPath || len(env) > 0 {
+				if err := c.RespawnPane(ref.Panes[0], dir, env.Pairs()...); err != nil {
+					return nil, fmt.Errorf(ErrFmtRespawnPane, win.Name, err)
+				}
+			}
+		} else {
+			ref, err = c.addWindow(sessionName, win, projectPath)
+			if err != nil {
+				return nil, err
 			}
 		}
+		ref.Name = win.Name
 
-		// Run command in the first pane if specified.
-		if len(win.Panes) > 0 && win.Panes[0].Command != "" {
-			paneTarget := fmt.Sprintf(FmtTargetPane0, target)
-			if err := SendKeys(paneTarget, win.Panes[0].Command); err != nil {
-				return fmt.Errorf(ErrFmtRunPaneCmd, 0, win.Name, err)
-			}
+		if err := c.buildPanes(&ref, win, projectPath); err != nil {
+			return nil, err
 		}
 
-		// Split panes (skip the first pane — it exists by default).
-		for j := 1; j < len(win.Panes); j++ {
-			pane := win.Panes[j]
-			args := []string{CmdSplitWindow, FlagTarget, target}
+		// Select the first pane after all splits. Safe to ignore: cosmetic
+		// focus operation — the layout is already applied at this point.
+		_ = c.SelectPane(ref.Panes[0])
+		refs = append(refs, ref)
+	}
 
-			// Default to horizontal split (side-by-side).
-			if pane.Split == SplitVertical {
-				args = append(args, FlagVertical)
-			} else {
-				args = append(args, FlagHoriz)
-			}
+	// Select the first window. Safe to ignore: cosmetic focus operation
+	// — all windows and panes are already created.
+	if len(refs) > 0 {
+		_ = c.SelectWindow(refs[0].ID)
+	}
+	return refs, nil
+}
 
-			if pane.Size != "" {
-				pct := strings.TrimSuffix(pane.Size, SizeSuffix)
-				args = append(args, FlagPrint, pct)
-			}
+// addWindow creates a window in the session with the directory and
+// environment of its first pane.
+func (c *Client) addWindow(session string, win config.Window, projectPath string) (WindowRef, error) {
+	ref, err := c.NewWindow(session, win.Name, paneDir(win, 0, projectPath), paneEnv(win, 0).Pairs()...)
+	if err != nil {
+		return WindowRef{}, fmt.Errorf(ErrFmtCreateWindow, win.Name, err)
+	}
+	return ref, nil
+}
 
-			args = append(args, FlagDir, projectPath)
+// paneDir returns the working directory of the window's i-th pane: its root,
+// else the window's root, else the project path. Roots are relative to the
+// project path.
+func paneDir(win config.Window, i int, projectPath string) string {
+	root := win.Root
+	if panes := win.Leaves(); i < len(panes) && panes[i].Root != "" {
+		root = panes[i].Root
+	}
+	if root == "" || filepath.IsAbs(root) {
+		return cmp.Or(root, projectPath)
+	}
+	return filepath.Join(projectPath, root)
+}
 
-			if err := RunSilent(args...); err != nil {
-				return fmt.Errorf(ErrFmtSplitPane, j, win.Name, err)
-			}
+// paneEnv returns the environment of the window's i-th pane: the window's
+// env overridden by the pane's.
+func paneEnv(win config.Window, i int) config.Env {
+	panes := win.Leaves()
+	if i >= len(panes) {
+		return win.Env
+	}
+	return win.Env.With(panes[i].Env)
+}
 
-			// Run command in this pane if specified.
-			if pane.Command != "" {
-				paneTarget := fmt.Sprintf(FmtTargetPaneN, target, j)
-				if err := SendKeys(paneTarget, pane.Command); err != nil {
-					return fmt.Errorf(ErrFmtRunPaneCmd, j, win.Name, err)
-				}
-			}
+// paneStep is one split of a window's build: it splits the pane at index
+// Target to create the pane at index Leaf, indexes being layout order.
+type paneStep struct {
+	Target  int
+	Leaf    int
+	Split   string // SplitVertical, else horizontal
+	Percent string // size of the new pane; empty for an even split
+}
+
+// panePlan returns the splits that build the window's panes from its first.
+// Each pane of a panes list splits the one before it.
+func panePlan(win config.Window) []paneStep {
+	if win.Tree != nil {
+		return treePlan(*win.Tree)
+	}
+	var steps []paneStep
+	for j := 1; j < len(win.Panes); j++ {
+		pane := win.Panes[j]
+		steps = append(steps, paneStep{
+			Target:  j - 1,
+			Leaf:    j,
+			Split:   pane.Split,
+			Percent: strings.TrimSuffix(pane.Size, SizeSuffix),
+		})
+	}
+	return steps
+}
+
+// buildPanes runs the first pane's command in the window's only pane, splits
+// off the rest of the window's panes and selects the window's tmux layout.
+func (c *Client) buildPanes(ref *WindowRef, win config.Window, projectPath string) error {
+	// Run command in the first pane if specified.
+	if panes := win.Leaves(); len(panes) > 0 && panes[0].Command != "" {
+		if err := c.SendKeys(ref.Panes[0], panes[0].Command); err != nil {
+			return fmt.Errorf(ErrFmtRunPaneCmd, 0, win.Name, err)
 		}
+	}
+	// Split panes (skip the first pane — it exists by default).
+	if err := c.splitPanes(ref, win, projectPath, 1); err != nil {
+		return err
+	}
+	if win.ReapplyLayout {
+		ref.Layout = win.Layout
+	}
+	return c.selectLayout(ref.ID, win)
+}
 
-		// Select the first pane after all splits. Safe to ignore: cosmetic
-		// focus operation — the layout is already applied at this point.
-		_ = SelectPane(target)
+// selectLayout arranges the panes of the target window with the window's
+// tmux layout, if it sets one.
+func (c *Client) selectLayout(target string, win config.Window) error {
+	if win.Layout == "" {
+		return nil
 	}
+	if _, err := c.Run(CmdSelectLayout, FlagTarget, target, win.Layout); err != nil {
+		return fmt.Errorf(ErrFmtSelectLayout, win.Name, err)
+	}
+	return nil
+}
 
-	// Select the first window. Safe to ignore: cosmetic focus operation
-	// — all windows and panes are already created.
-	_ = SelectWindow(fmt.Sprintf(FmtSessionFirst, sessionName))
+// splitPanes creates the window's panes from index from on and runs their
+// commands. Each split targets a pane by its ID, so ref.Panes must hold the
+// IDs of the panes before from, in layout order; on return it holds them all.
+func (c *Client) splitPanes(ref *WindowRef, win config.Window, projectPath string, from int) error {
+	panes, steps := win.Leaves(), panePlan(win)
+	ids := make([]string, max(len(panes), len(ref.Panes)))
+	copy(ids, ref.Panes)
+
+	for k := from - 1; k < len(steps); k++ {
+		step := steps[k]
+		j := step.Leaf
+		args := []string{CmdSplitWindow, FlagTarget, ids[step.Target]}
+
+		// Default to horizontal split (side-by-side).
+		if step.Split == SplitVertical {
+			args = append(args, FlagVertical)
+		} else {
+			args = append(args, FlagHoriz)
+		}
+
+		if step.Percent != "" {
+			args = append(args, FlagPrint, step.Percent)
+		}
+
+		args = append(args, FlagDir, paneDir(win, j, projectPath), FlagPrintInfo, FlagFormat, PaneIDFormat)
+		args = append(args, envArgs(paneEnv(win, j).Pairs())...)
+
+		paneID, err := c.Run(args...)
+		if err != nil {
+			return fmt.Errorf(ErrFmtSplitPane, j, win.Name, err)
+		}
+		ids[j] = strings.TrimSpace(paneID)
+
+		// Run command in this pane if specified.
+		if panes[j].Command != "" {
+			if err := c.SendKeys(ids[j], panes[j].Command); err != nil {
+				return fmt.Errorf(ErrFmtRunPaneCmd, j, win.Name, err)
+			}
+		}
+	}
+	ref.Panes = ids
 	return nil
 }
 
-// RunOnStart sends the on_start commands to the appropriate windows.
-func RunOnStart(sessionName string, layout config.Layout, commands []config.OnStart) error {
-	// Build a map of window name -> index.
-	winIndex := make(map[string]int)
-	for i, w := range layout.Windows {
-		winIndex[w.Name] = i
+// RunOnStart sends the on_start commands to the first pane of the named
+// windows, as built by ApplyLayout. A command with wait_for holds back the
+// ones after it until its condition is met; files are relative to dir. Then
+// it selects the layout of the windows that reapply it.
+func (c *Client) RunOnStart(windows []WindowRef, commands []config.OnStart, dir string) error {
+	// Build a map of window name -> first pane ID.
+	firstPane := make(map[string]string)
+	for _, w := range windows {
+		if _, dup := firstPane[w.Name]; !dup && len(w.Panes) > 0 {
+			firstPane[w.Name] = w.Panes[0]
+		}
 	}
 
 	for _, cmd := range commands {
-		idx, ok := winIndex[cmd.Window]
+		target, ok := firstPane[cmd.Window]
 		if !ok {
 			continue // Skip if window not found in layout.
 		}
-		target := fmt.Sprintf(FmtSessionWindowPane, sessionName, idx)
-		if err := SendKeys(target, cmd.Command); err != nil {
+		if err := c.SendKeys(target, cmd.Command); err != nil {
 			return fmt.Errorf(ErrFmtRunOnStart, cmd.Window, err)
 		}
+		if cmd.WaitFor != nil {
+			if err := c.waitFor(cmd.WaitFor, target, cmd.Command, dir); err != nil {
+				return fmt.Errorf(ErrFmtWaitFor, cmd.Window, err)
+			}
+		}
+	}
+
+	for _, w := range windows {
+		if w.Layout == "" {
+			continue
+		}
+		if _, err := c.Run(CmdSelectLayout, FlagTarget, w.ID, w.Layout); err != nil {
+			return fmt.Errorf(ErrFmtSelectLayout, w.Name, err)
+		}
 	}
 	return nil
 }
 
-func shellEscape(s string) string {
-	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+// activeWindow returns the IDs of the session's active window and pane, which
+// for a new session are its only window and pane.
+func (c *Client) activeWindow(session string) (WindowRef, error) {
+	out, err := c.Run(CmdDisplayMessage, FlagPrint, FlagTarget, session, WindowPaneIDFormat)
+	if err != nil {
+		return WindowRef{}, err
+	}
+	return parseWindowRef(out)
+}
+
+// parseWindowRef parses "window_id<TAB>pane_id" as printed by WindowPaneIDFormat.
+func parseWindowRef(out string) (WindowRef, error) {
+	windowID, paneID, ok := strings.Cut(strings.TrimSpace(out), "\t")
+	if !ok {
+		return WindowRef{}, fmt.Errorf(ErrFmtParseIDs, out)
+	}
+	return WindowRef{ID: windowID, Panes: []string{paneID}}, nil
 }
@@ -0,0 +1,281 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+// Commands ApplyLayout issues to learn the IDs of the session's first window.
+const queryFirstWindow = "display-message -p -t api #{window_id}\t#{pane_id}"
+
+func TestApplyLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout config.Layout
+		want   []string
+	}{
+		{
+			name:   "single window",
+			layout: config.Layout{Windows: []config.Window{{Name: "main"}}},
+			want: []string{
+				queryFirstWindow,
+				"rename-window -t @0 main",
+				"select-pane -t %0",
+				"select-window -t @0",
+			},
+		},
+		{
+			name: "splits and pane commands",
+			layout: config.Layout{Windows: []config.Window{
+				{Name: "editor", Panes: []config.Pane{
+					{Size: "70%", Command: "nvim ."},
+					{Split: "horizontal", Size: "30%"},
+					{Split: "vertical", Size: "50%", Command: "make test"},
+				}},
+			}},
+			want: []string{
+				queryFirstWindow,
+				"rename-window -t @0 editor",
+				"send-keys -t %0 nvim . Enter",
+				"split-window -t %0 -h -p 30 -c /src/api -P -F #{pane_id}",
+				"split-window -t %1 -v -p 50 -c /src/api -P -F #{pane_id}",
+				"send-keys -t %2 make test Enter",
+				"select-pane -t %0",
+				"select-window -t @0",
+			},
+		},
+		{
+			name: "extra windows start in project path",
+			layout: config.Layout{Windows: []config.Window{
+				{Name: "editor"},
+				{Name: "server", Panes: []config.Pane{{Command: "go run ."}}},
+			}},
+			want: []string{
+				queryFirstWindow,
+				"rename-window -t @0 editor",
+				"select-pane -t %0",
+				"new-window -t api -n server -c /src/api -P -F #{window_id}\t#{pane_id}",
+				"send-keys -t %1 go run . Enter",
+				"select-pane -t %1",
+				"select-window -t @0",
+			},
+		},
+		{
+			name: "window and pane roots",
+			layout: config.Layout{Windows: []config.Window{
+				{Name: "web", Root: "web"},
+				{Name: "server", Root: "services/api", Panes: []config.Pane{
+					{},
+					{Split: "vertical", Root: "/var/log"},
+					{Split: "horizontal"},
+				}},
+			}},
+			want: []string{
+				queryFirstWindow,
+				"rename-window -t @0 web",
+				"respawn-pane -k -t %0 -c /src/api/web",
+				"select-pane -t %0",
+				"new-window -t api -n server -c /src/api/services/api -P -F #{window_id}\t#{pane_id}",
+				"split-window -t %1 -v -c /var/log -P -F #{pane_id}",
+				"split-window -t %2 -h -c /src/api/services/api -P -F #{pane_id}",
+				"select-pane -t %1",
+				"select-window -t @0",
+			},
+		},
+		{
+			name: "window and pane env",
+			layout: config.Layout{Windows: []config.Window{
+				{Name: "editor", Env: config.Env{"EDITOR": "nvim"}},
+				{Name: "server", Env: config.Env{"PORT": "8080", "DEBUG": "0"}, Panes: []config.Pane{
+					{},
+					{Split: "horizontal", Env: config.Env{"DEBUG": "1"}},
+				}},
+			}},
+			want: []string{
+				queryFirstWindow,
+				"rename-window -t @0 editor",
+				"respawn-pane -k -t %0 -c /src/api -e EDITOR=nvim",
+				"select-pane -t %0",
+				"new-window -t api -n server -c /src/api -P -F #{window_id}\t#{pane_id} -e DEBUG=0 -e PORT=8080",
+				"split-window -t %1 -h -c /src/api -P -F #{pane_id} -e DEBUG=1 -e PORT=8080",
+				"select-pane -t %1",
+				"select-window -t @0",
+			},
+		},
+		{
+			name: "tmux layout",
+			layout: config.Layout{Windows: []config.Window{
+				{Name: "logs", Layout: "tiled", Panes: []config.Pane{{}, {}, {}}},
+			}},
+			want: []string{
+				queryFirstWindow,
+				"rename-window -t @0 logs",
+				"split-window -t %0 -h -c /src/api -P -F #{pane_id}",
+				"split-window -t %1 -h -c /src/api -P -F #{pane_id}",
+				"select-layout -t @0 tiled",
+				"select-pane -t %0",
+				"select-window -t @0",
+			},
+		},
+		{
+			name: "editor beside stacked panes",
+			layout: config.Layout{Windows: []config.Window{
+				{Name: "dev", Tree: &config.PaneNode{Split: "horizontal", Children: []config.PaneNode{
+					{Size: "60%", Command: "nvim ."},
+					{Split: "vertical", Children: []config.PaneNode{
+						{Command: "make watch"},
+						{Root: "web"},
+						{Size: "20%", Env: config.Env{"PORT": "3000"}},
+					}},
+				}}},
+			}},
+			want: []string{
+				queryFirstWindow,
+				"rename-window -t @0 dev",
+				"send-keys -t %0 nvim . Enter",
+				"split-window -t %0 -h -p 40 -c /src/api -P -F #{pane_id}",
+				"send-keys -t %1 make watch Enter",
+				"split-window -t %1 -v -p 60 -c /src/api/web -P -F #{pane_id}",
+				"split-window -t %2 -v -p 33 -c /src/api -P -F #{pane_id} -e PORT=3000",
+				"select-pane -t %0",
+				"select-window -t @0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, base := range []int{0, 1} {
+			t.Run(fmt.Sprintf("%s/base-index %d", tt.name, base), func(t *testing.T) {
+				fake := tmuxtest.New()
+				fake.BaseIndex, fake.PaneBaseIndex = base, base
+				fake.AddSession("api", "/src/api")
+
+				refs, err := NewClient(fake).ApplyLayout("api", tt.layout, "/src/api")
+				if err != nil {
+					t.Fatalf("ApplyLayout() error = %v", err)
+				}
+				assertCommands(t, fake.Commands(), tt.want)
+
+				s := fake.Session("api")
+				if len(s.Windows) != len(tt.layout.Windows) || len(refs) != len(tt.layout.Windows) {
+					t.Fatalf("session has %d windows (%d refs), want %d", len(s.Windows), len(refs), len(tt.layout.Windows))
+				}
+				for i, win := range tt.layout.Windows {
+					if s.Windows[i].Name != win.Name || refs[i].Name != win.Name {
+						t.Errorf("window %d name = %q (ref %q), want %q", i, s.Windows[i].Name, refs[i].Name, win.Name)
+					}
+					if refs[i].ID != s.Windows[i].ID {
+						t.Errorf("window %d ref ID = %q, want %q", i, refs[i].ID, s.Windows[i].ID)
+					}
+					wantPanes := max(len(win.Leaves()), 1)
+					if len(s.Windows[i].Panes) != wantPanes || len(refs[i].Panes) != wantPanes {
+						t.Errorf("window %q has %d panes (%d refs), want %d", win.Name, len(s.Windows[i].Panes), len(refs[i].Panes), wantPanes)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestApplyLayoutGrid(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api")
+
+	column := func(top, bottom string) config.PaneNode {
+		return config.PaneNode{Split: "vertical", Children: []config.PaneNode{{Command: top}, {Command: bottom}}}
+	}
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "grid", Tree: &config.PaneNode{Children: []config.PaneNode{column("a", "b"), column("c", "d")}}},
+	}}
+	refs, err := NewClient(fake).ApplyLayout("api", layout, "/src/api")
+	if err != nil {
+		t.Fatalf("ApplyLayout() error = %v", err)
+	}
+	assertCommands(t, fake.Commands(), []string{
+		queryFirstWindow,
+		"rename-window -t @0 grid",
+		"send-keys -t %0 a Enter",
+		"split-window -t %0 -h -p 50 -c /src/api -P -F #{pane_id}",
+		"send-keys -t %1 c Enter",
+		"split-window -t %0 -v -p 50 -c /src/api -P -F #{pane_id}",
+		"send-keys -t %2 b Enter",
+		"split-window -t %1 -v -p 50 -c /src/api -P -F #{pane_id}",
+		"send-keys -t %3 d Enter",
+		"select-pane -t %0",
+		"select-window -t @0",
+	})
+	if got, want := strings.Join(refs[0].Panes, " "), "%0 %2 %1 %3"; got != want {
+		t.Errorf("pane refs = %s, want layout order %s", got, want)
+	}
+}
+
+func TestApplyLayoutError(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api")
+	fake.Fail = map[string]string{CmdSplitWindow: "no space for new pane"}
+
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Panes: []config.Pane{{}, {Split: "horizontal"}}},
+	}}
+	_, err := NewClient(fake).ApplyLayout("api", layout, "/src/api")
+	if err == nil || !strings.Contains(err.Error(), `splitting pane 1 in window "editor"`) {
+		t.Errorf("ApplyLayout() error = %v, want split error", err)
+	}
+}
+
+func TestRunOnStart(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api", "editor", "server")
+
+	windows := []WindowRef{
+		{Name: "editor", ID: "@0", Panes: []string{"%0"}},
+		{Name: "server", ID: "@1", Panes: []string{"%1"}},
+	}
+	commands := []config.OnStart{
+		{Window: "server", Command: "go run ."},
+		{Window: "missing", Command: "ignored"},
+		{Window: "editor", Command: "nvim ."},
+	}
+	if err := NewClient(fake).RunOnStart(windows, commands, ""); err != nil {
+		t.Fatalf("RunOnStart() error = %v", err)
+	}
+	assertCommands(t, fake.Commands(), []string{
+		"send-keys -t %1 go run . Enter",
+		"send-keys -t %0 nvim . Enter",
+	})
+}
+
+func TestRunOnStartReappliesLayout(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api")
+
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Layout: "main-vertical", ReapplyLayout: true, Panes: []config.Pane{{}, {}}},
+		{Name: "logs", Layout: "tiled", Panes: []config.Pane{{}, {}}},
+	}}
+	client := NewClient(fake)
+	refs, err := client.ApplyLayout("api", layout, "/src/api")
+	if err != nil {
+		t.Fatalf("ApplyLayout() error = %v", err)
+	}
+	fake.Reset()
+	if err := client.RunOnStart(refs, []config.OnStart{{Window: "editor", Command: "nvim ."}}, ""); err != nil {
+		t.Fatalf("RunOnStart() error = %v", err)
+	}
+	assertCommands(t, fake.Commands(), []string{
+		"send-keys -t %0 nvim . Enter",
+		"select-layout -t @0 main-vertical",
+	})
+}
+
+func assertCommands(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tmux commands:\n  got:\n    %s\n  want:\n    %s",
+			strings.Join(got, "\n    "), strings.Join(want, "\n    "))
+	}
+}
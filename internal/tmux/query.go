@@ -19,8 +19,8 @@ type SessionInfo struct {
 }
 
 // ListSessions returns all active tmux sessions.
-func ListSessions() ([]SessionInfo, error) {
-	out, err := Run(CmdListSessions, FlagFormat, SessionListFormat)
+func (c *Client) ListSessions() ([]SessionInfo, error) {
+	out, err := c.Run(CmdListSessions, FlagFormat, SessionListFormat)
 	if err != nil {
 		// No server running means no sessions.
 		if strings.Contains(err.Error(), ErrNoServer) || strings.Contains(err.Error(), ErrNoCurrent) {
@@ -63,8 +63,8 @@ type WindowInfo struct {
 }
 
 // ListWindows returns all windows for the given session.
-func ListWindows(session string) ([]WindowInfo, error) {
-	out, err := Run(CmdListWindows, FlagTarget, session, FlagFormat, WindowListFormat)
+func (c *Client) ListWindows(session string) ([]WindowInfo, error) {
+	out, err := c.Run(CmdListWindows, FlagTarget, session, FlagFormat, WindowListFormat)
 	if err != nil {
 		return nil, err
 	}
@@ -94,9 +94,73 @@ func ListWindows(session string) ([]WindowInfo, error) {
 	return windows, nil
 }
 
+// PaneInfo holds metadata about a tmux pane, including its position and size
+// in cells within its window.
+type PaneInfo struct {
+	ID      string
+	Index   int
+	Active  bool
+	Left    int
+	Top     int
+	Width   int
+	Height  int
+	Command string // foreground process, e.g. "nvim" or the shell
+	Path    string // current working directory
+}
+
+// ListPanes returns the panes of the target window in index order.
+func (c *Client) ListPanes(window string) ([]PaneInfo, error) {
+	out, err := c.Run(CmdListPanes, FlagTarget, window, FlagFormat, PaneListFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	if out == "" {
+		return nil, nil
+	}
+
+	lines := strings.Split(out, "\n")
+	panes := make([]PaneInfo, 0, len(lines))
+	for _, line := range lines {
+		parts := strings.SplitN(line, "\t", PaneFieldCount)
+		if len(parts) < PaneFieldCount {
+			continue
+		}
+		var nums [5]int
+		for i, field := range []string{parts[1], parts[3], parts[4], parts[5], parts[6]} {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf(ErrFmtParsePane, parts[0], err)
+			}
+			nums[i] = n
+		}
+		panes = append(panes, PaneInfo{
+			ID:      parts[0],
+			Index:   nums[0],
+			Active:  parts[2] == ActiveValue,
+			Left:    nums[1],
+			Top:     nums[2],
+			Width:   nums[3],
+			Height:  nums[4],
+			Command: parts[7],
+			Path:    parts[8],
+		})
+	}
+	return panes, nil
+}
+
+// SessionPath returns the working directory of the session.
+func (c *Client) SessionPath(session string) (string, error) {
+	out, err := c.Run(CmdDisplayMessage, FlagPrint, FlagTarget, session, SessionPathFormat)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
 // CurrentSession returns the name of the session the current client is attached to.
-func CurrentSession() (string, error) {
-	out, err := Run(CmdDisplayMessage, FlagPrint, SessionNameFormat)
+func (c *Client) CurrentSession() (string, error) {
+	out, err := c.Run(CmdDisplayMessage, FlagPrint, SessionNameFormat)
 	if err != nil {
 		return "", err
 	}
@@ -107,8 +171,8 @@ func CurrentSession() (string, error) {
 // before killing the current one. It returns the next session in the list,
 // or the previous one if current is last. If current is the only session,
 // it returns "", false.
-func NeighborSession(current string) (string, bool) {
-	sessions, err := ListSessions()
+func (c *Client) NeighborSession(current string) (string, bool) {
+	sessions, err := c.ListSessions()
 	if err != nil || len(sessions) <= 1 {
 		return "", false
 	}
@@ -125,7 +189,14 @@ func NeighborSession(current string) (string, bool) {
 }
 
 // SessionExists checks if a session with the given name exists.
-func SessionExists(name string) bool {
-	err := RunSilent(CmdHasSession, FlagTarget, name)
+func (c *Client) SessionExists(name string) bool {
+	err := c.RunSilent(CmdHasSession, FlagTarget, name)
 	return err == nil
 }
+
+// CapturePane returns the visible contents of the target pane with ANSI
+// colors and attributes preserved. A session or window target captures its
+// active pane.
+func (c *Client) CapturePane(target string) (string, error) {
+	return c.Run(CmdCapturePane, FlagPrint, FlagEscapes, FlagTarget, target)
+}
@@ -1,27 +1,146 @@
 package tmux
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
 
 func TestNeighborSession(t *testing.T) {
-	// NeighborSession depends on ListSessions which calls tmux.
-	// We test the logic by verifying behavior when tmux is not running
-	// (returns no sessions).
-	t.Run("no sessions returns false", func(t *testing.T) {
-		name, ok := NeighborSession("nonexistent")
-		if ok {
-			t.Errorf("NeighborSession() ok = true, want false")
-		}
-		if name != "" {
-			t.Errorf("NeighborSession() name = %q, want empty", name)
-		}
-	})
+	tests := []struct {
+		name     string
+		sessions []string
+		current  string
+		want     string
+		wantOK   bool
+	}{
+		{name: "no sessions returns false", current: "nonexistent"},
+		{name: "only session returns false", sessions: []string{"a"}, current: "a"},
+		{name: "picks next session", sessions: []string{"a", "b", "c"}, current: "b", want: "c", wantOK: true},
+		{name: "picks previous when last", sessions: []string{"a", "b", "c"}, current: "c", want: "b", wantOK: true},
+		{name: "unknown current returns false", sessions: []string{"a", "b"}, current: "z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := tmuxtest.New()
+			for _, s := range tt.sessions {
+				fake.AddSession(s, "/tmp")
+			}
+			name, ok := NewClient(fake).NeighborSession(tt.current)
+			if ok != tt.wantOK {
+				t.Errorf("NeighborSession() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if name != tt.want {
+				t.Errorf("NeighborSession() name = %q, want %q", name, tt.want)
+			}
+		})
+	}
 }
 
 func TestSessionExists(t *testing.T) {
+	fake := tmuxtest.New()
+	c := NewClient(fake)
+
+	t.Run("no server returns false", func(t *testing.T) {
+		if c.SessionExists("__tplm_test_nonexistent__") {
+			t.Error("SessionExists() = true with no server running")
+		}
+	})
+
+	fake.AddSession("api", "/tmp")
+
 	t.Run("nonexistent session returns false", func(t *testing.T) {
-		// This will fail gracefully when tmux is not running.
-		if SessionExists("__tplm_test_nonexistent__") {
+		if c.SessionExists("__tplm_test_nonexistent__") {
 			t.Error("SessionExists() = true for nonexistent session")
 		}
 	})
+
+	t.Run("existing session returns true", func(t *testing.T) {
+		if !c.SessionExists("api") {
+			t.Error("SessionExists() = false for existing session")
+		}
+	})
+}
+
+func TestListSessions(t *testing.T) {
+	t.Run("no server returns no sessions", func(t *testing.T) {
+		sessions, err := NewClient(tmuxtest.New()).ListSessions()
+		if err != nil {
+			t.Fatalf("ListSessions() error = %v", err)
+		}
+		if len(sessions) != 0 {
+			t.Errorf("ListSessions() = %v, want none", sessions)
+		}
+	})
+
+	t.Run("parses session fields", func(t *testing.T) {
+		fake := tmuxtest.New()
+		fake.AddSession("api", "/src/api", "editor", "server")
+		fake.AddSession("web", "/src/web")
+		fake.Client = "web"
+
+		sessions, err := NewClient(fake).ListSessions()
+		if err != nil {
+			t.Fatalf("ListSessions() error = %v", err)
+		}
+		want := []SessionInfo{
+			{Name: "api", Windows: 2, Attached: false, Path: "/src/api"},
+			{Name: "web", Windows: 1, Attached: true, Path: "/src/web"},
+		}
+		if len(sessions) != len(want) {
+			t.Fatalf("ListSessions() = %v, want %v", sessions, want)
+		}
+		for i := range want {
+			if sessions[i] != want[i] {
+				t.Errorf("ListSessions()[%d] = %+v, want %+v", i, sessions[i], want[i])
+			}
+		}
+	})
+}
+
+func TestListWindows(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api", "editor", "server")
+
+	windows, err := NewClient(fake).ListWindows("api")
+	if err != nil {
+		t.Fatalf("ListWindows() error = %v", err)
+	}
+	want := []WindowInfo{
+		{Index: 0, Name: "editor", Active: true},
+		{Index: 1, Name: "server", Active: false},
+	}
+	if len(windows) != len(want) {
+		t.Fatalf("ListWindows() = %v, want %v", windows, want)
+	}
+	for i := range want {
+		if windows[i] != want[i] {
+			t.Errorf("ListWindows()[%d] = %+v, want %+v", i, windows[i], want[i])
+		}
+	}
+}
+
+func TestCapturePane(t *testing.T) {
+	fake := tmuxtest.New()
+	s := fake.AddSession("api", "/src/api", "editor", "server")
+	s.Windows[0].Panes[0].Content = "\x1b[32m$\x1b[0m make test"
+	s.Windows[1].Panes[0].Content = "listening on :8080"
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "api", want: "\x1b[32m$\x1b[0m make test"},
+		{target: "api:1", want: "listening on :8080"},
+	}
+	for _, tt := range tests {
+		got, err := NewClient(fake).CapturePane(tt.target)
+		if err != nil {
+			t.Fatalf("CapturePane(%q) error = %v", tt.target, err)
+		}
+		if got != tt.want {
+			t.Errorf("CapturePane(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
 }
@@ -0,0 +1,108 @@
+package tmux
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+// Reconciliation lists what ReconcileLayout changed in a session.
+type Reconciliation struct {
+	Added  []WindowRef    // windows created from the layout, in layout order
+	Split  map[string]int // panes added per existing window
+	Pruned []string       // windows killed because the layout doesn't have them
+}
+
+// Changed reports whether the session was modified.
+func (r Reconciliation) Changed() bool {
+	return len(r.Added) > 0 || len(r.Split) > 0 || len(r.Pruned) > 0
+}
+
+// ReconcileLayout brings a running session in line with its layout without
+// touching the panes it already has: it creates the windows the session lacks
+// and splits off the panes missing from existing windows, running commands
+// only in the new panes. Renamed windows count as existing. With prune, it also
+// kills the windows the layout doesn't have. The added windows can be passed
+// to RunOnStart.
+func (c *Client) ReconcileLayout(session string, layout config.Layout, projectPath string, prune bool) (Reconciliation, error) {
+	drift, err := c.DiffSession(session, layout)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+	r := Reconciliation{Split: make(map[string]int)}
+
+	// Split existing windows first, while the live indexes are still valid.
+	// Drift lists the layout's windows first, in layout order.
+	var extra []WindowDiff
+	for i, w := range drift.Windows {
+		switch {
+		case w.Extra():
+			extra = append(extra, w)
+		case !w.Missing() && w.GotPanes < w.WantPanes:
+			added, err := c.addPanes(session, w, layout.Windows[i], projectPath)
+			if err != nil {
+				return r, err
+			}
+			if added > 0 {
+				r.Split[w.LiveName] = added
+			}
+		}
+	}
+
+	// Kill from the highest index down so renumber-windows can't shift the
+	// windows still to be killed.
+	if prune {
+		sort.Slice(extra, func(a, b int) bool { return extra[a].LiveIndex > extra[b].LiveIndex })
+		for _, w := range extra {
+			if err := c.KillWindow(fmt.Sprintf(FmtSessionWindow, session, w.LiveIndex)); err != nil {
+				return r, fmt.Errorf(ErrFmtKillWindow, w.LiveName, err)
+			}
+			r.Pruned = append(r.Pruned, w.LiveName)
+		}
+	}
+
+	for i, w := range drift.Windows {
+		if !w.Missing() {
+			continue
+		}
+		win := layout.Windows[i]
+		ref, err := c.addWindow(session, win, projectPath)
+		if err != nil {
+			return r, err
+		}
+		ref.Name = win.Name
+		if err := c.buildPanes(&ref, win, projectPath); err != nil {
+			return r, err
+		}
+		// Safe to ignore: cosmetic focus operation.
+		_ = c.SelectPane(ref.Panes[0])
+		r.Added = append(r.Added, ref)
+	}
+	return r, nil
+}
+
+// addPanes splits off the layout panes a live window is missing, continuing
+// from its last pane, selects the window's tmux layout again and returns how
+// many panes it added. A pane tree is only built out from a single pane:
+// which of its panes a window with several already has can't be told, so
+// such a window is left alone.
+func (c *Client) addPanes(session string, w WindowDiff, win config.Window, projectPath string) (int, error) {
+	if win.Tree != nil && w.GotPanes > 1 {
+		return 0, nil
+	}
+	panes, err := c.ListPanes(fmt.Sprintf(FmtSessionWindow, session, w.LiveIndex))
+	if err != nil {
+		return 0, fmt.Errorf(ErrFmtReadWindow, w.LiveName, err)
+	}
+	ref := WindowRef{Name: w.LiveName}
+	for _, p := range panes {
+		ref.Panes = append(ref.Panes, p.ID)
+	}
+	win.Name = w.LiveName
+	had := len(ref.Panes)
+	if err := c.splitPanes(&ref, win, projectPath, had); err != nil {
+		return 0, err
+	}
+	return len(ref.Panes) - had, c.selectLayout(fmt.Sprintf(FmtSessionWindow, session, w.LiveIndex), win)
+}
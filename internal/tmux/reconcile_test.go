@@ -0,0 +1,101 @@
+package tmux
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+func TestReconcileLayout(t *testing.T) {
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Panes: []config.Pane{
+			{Command: "nvim ."},
+			{Split: "horizontal", Size: "30%"},
+			{Split: "vertical", Command: "make watch"},
+		}},
+		{Name: "server", Panes: []config.Pane{{Command: "go run ."}}},
+		{Name: "logs"},
+	}}
+
+	for _, prune := range []bool{false, true} {
+		name := "keep extra windows"
+		if prune {
+			name = "prune extra windows"
+		}
+		t.Run(name, func(t *testing.T) {
+			fake := tmuxtest.New()
+			// editor has its first two panes, server is gone and scratch
+			// was added.
+			s := fake.AddSession("api", "/src/api", "editor", "logs", "scratch")
+			editor := s.Windows[0]
+			editor.Panes = append(editor.Panes, &tmuxtest.Pane{ID: "%50", Path: "/src/api"})
+			fake.Reset()
+
+			r, err := NewClient(fake).ReconcileLayout("api", layout, "/src/api", prune)
+			if err != nil {
+				t.Fatalf("ReconcileLayout() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(r.Split, map[string]int{"editor": 1}) {
+				t.Errorf("Split = %v, want editor +1", r.Split)
+			}
+			if len(r.Added) != 1 || r.Added[0].Name != "server" {
+				t.Errorf("Added = %+v, want server", r.Added)
+			}
+
+			// Only the new pane of editor and the new server window run commands.
+			if len(editor.Panes) != 3 {
+				t.Fatalf("editor has %d panes, want 3", len(editor.Panes))
+			}
+			for i, want := range []string{"", "", "make watch Enter"} {
+				if got := strings.Join(editor.Panes[i].Keys, "|"); got != want {
+					t.Errorf("editor pane %d keys = %q, want %q", i, got, want)
+				}
+			}
+			if split := editor.Panes[2]; split.Split != "-v" {
+				t.Errorf("new editor pane split %q, want -v", split.Split)
+			}
+			server := s.Window("server")
+			if server == nil || strings.Join(server.Panes[0].Keys, "|") != "go run . Enter" || server.Panes[0].Path != "/src/api" {
+				t.Errorf("server window = %+v, want it created in /src/api running go run .", server)
+			}
+			if logs := s.Window("logs"); logs == nil || len(logs.Panes[0].Keys) > 0 {
+				t.Errorf("logs window = %+v, want it untouched", logs)
+			}
+
+			if got := s.Window("scratch") != nil; got == prune {
+				t.Errorf("scratch kept = %v with prune %v", got, prune)
+			}
+			if prune && !reflect.DeepEqual(r.Pruned, []string{"scratch"}) {
+				t.Errorf("Pruned = %v, want scratch", r.Pruned)
+			}
+		})
+	}
+}
+
+func TestReconcileLayoutTreeWithSeveralPanes(t *testing.T) {
+	layout := config.Layout{Windows: []config.Window{
+		{Name: "editor", Tree: &config.PaneNode{Children: []config.PaneNode{
+			{Command: "nvim ."},
+			{Split: "vertical", Children: []config.PaneNode{{}, {Command: "make watch"}}},
+		}}},
+	}}
+	fake := tmuxtest.New()
+	s := fake.AddSession("api", "/src/api", "editor")
+	editor := s.Windows[0]
+	editor.Panes = append(editor.Panes, &tmuxtest.Pane{ID: "%50", Path: "/src/api"})
+
+	r, err := NewClient(fake).ReconcileLayout("api", layout, "/src/api", false)
+	if err != nil {
+		t.Fatalf("ReconcileLayout() error = %v", err)
+	}
+	if len(editor.Panes) != 2 {
+		t.Errorf("editor has %d panes, want its 2 left alone", len(editor.Panes))
+	}
+	if len(r.Split) != 0 || r.Changed() {
+		t.Errorf("Split = %v, want nothing reported for the untouched window", r.Split)
+	}
+}
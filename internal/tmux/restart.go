@@ -0,0 +1,121 @@
+package tmux
+
+import "fmt"
+
+// Focus is the window and pane a session shows, kept across a restart. Pane
+// is the pane's position in its window, which matches its layout index.
+type Focus struct {
+	Window string
+	Pane   int
+}
+
+// SessionFocus returns the session's active window and pane.
+func (c *Client) SessionFocus(session string) (Focus, error) {
+	windows, err := c.ListWindows(session)
+	if err != nil {
+		return Focus{}, err
+	}
+	for _, w := range windows {
+		if !w.Active {
+			continue
+		}
+		panes, err := c.ListPanes(fmt.Sprintf(FmtSessionWindow, session, w.Index))
+		if err != nil {
+			return Focus{}, err
+		}
+		for i, p := range panes {
+			if p.Active {
+				return Focus{Window: w.Name, Pane: i}, nil
+			}
+		}
+		return Focus{Window: w.Name}, nil
+	}
+	return Focus{}, nil
+}
+
+// RestoreFocus selects the focused window and pane in the session again. It
+// does nothing for a window or pane the session no longer has.
+func (c *Client) RestoreFocus(session string, f Focus) error {
+	windows, err := c.ListWindows(session)
+	if err != nil {
+		return err
+	}
+	for _, w := range windows {
+		if w.Name != f.Window {
+			continue
+		}
+		target := fmt.Sprintf(FmtSessionWindow, session, w.Index)
+		if err := c.SelectWindow(target); err != nil {
+			return err
+		}
+		panes, err := c.ListPanes(target)
+		if err != nil {
+			return err
+		}
+		if f.Pane < len(panes) {
+			return c.SelectPane(panes[f.Pane].ID)
+		}
+		return nil
+	}
+	return nil
+}
+
+// RestartSession kills the session and calls rebuild to create it again, then
+// restores the window and pane that had focus. When the client is in the
+// session, it waits on a neighbor session during the rebuild and comes back
+// afterwards. If there is no other session, the old one is kept under a
+// temporary name until the new one exists, so the server doesn't exit; if
+// rebuild fails, it gets its name back.
+func (c *Client) RestartSession(name string, rebuild func() error) error {
+	focus, err := c.SessionFocus(name)
+	if err != nil {
+		return err
+	}
+
+	// Outside tmux, display-message reports some session without there being
+	// a client to switch.
+	current := ""
+	if InsideTmux() {
+		current, _ = c.CurrentSession()
+	}
+	old := ""
+	neighbor, ok := c.NeighborSession(name)
+	switch {
+	case !ok:
+		old = RestartPrefix + name
+		err = c.RenameSession(name, old)
+	case current == name:
+		if err = c.SwitchClient(neighbor); err == nil {
+			err = c.KillSession(name)
+		}
+	default:
+		err = c.KillSession(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := rebuild(); err != nil {
+		if old != "" {
+			// Put the old session back under its name, in place of whatever
+			// part of the new one was created.
+			if c.SessionExists(name) {
+				_ = c.KillSession(name)
+			}
+			_ = c.RenameSession(old, name)
+		}
+		return err
+	}
+	// Safe to ignore: cosmetic focus operation — the session is rebuilt.
+	_ = c.RestoreFocus(name, focus)
+
+	if current == name {
+		if err := c.SwitchClient(name); err != nil {
+			return err
+		}
+	}
+	if old != "" {
+		return c.KillSession(old)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package tmux
+
+import (
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+func TestRestartSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		others    []string
+		client    string
+		wantCalls []string // commands expected in order, among others
+	}{
+		{name: "other session", others: []string{"web"}, client: "web", wantCalls: []string{
+			"kill-session -t api",
+		}},
+		{name: "current session waits on neighbor", others: []string{"web"}, client: "api", wantCalls: []string{
+			"switch-client -t web", "kill-session -t api", "switch-client -t api",
+		}},
+		{name: "only session is kept until rebuilt", client: "api", wantCalls: []string{
+			"rename-session -t api restarting-api", "switch-client -t api", "kill-session -t restarting-api",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvTmux, "/tmp/tmux-1000/default,1,0")
+			fake := tmuxtest.New()
+			s := fake.AddSession("api", "/src/api", "editor", "server")
+			s.Windows[1].Panes = append(s.Windows[1].Panes, &tmuxtest.Pane{ID: "%90"})
+			s.Windows[1].Panes[0].Active = false
+			s.Windows[1].Panes[1].Active = true
+			s.Windows[0].Active, s.Windows[1].Active = false, true
+			for _, o := range tt.others {
+				fake.AddSession(o, "/tmp")
+			}
+			fake.Client = tt.client
+			c := NewClient(fake)
+
+			rebuild := func() error {
+				if fake.Session("api") != nil {
+					t.Error("rebuild called while session api still exists")
+				}
+				if err := c.NewSession("api", "/src/api"); err != nil {
+					return err
+				}
+				fresh := fake.Session("api")
+				fresh.Windows[0].Name = "editor"
+				c.NewWindow("api", "server", "/src/api")
+				if _, err := c.Run("split-window", "-t", "api:server", "-P", "-F", "#{pane_id}"); err != nil {
+					return err
+				}
+				c.SelectWindow("api:editor")
+				return nil
+			}
+			if err := c.RestartSession("api", rebuild); err != nil {
+				t.Fatalf("RestartSession() error = %v", err)
+			}
+
+			if fake.Client != tt.client {
+				t.Errorf("client on %q, want %q", fake.Client, tt.client)
+			}
+			if len(fake.Sessions) != 1+len(tt.others) {
+				t.Errorf("got %d sessions, want %d", len(fake.Sessions), 1+len(tt.others))
+			}
+			fresh := fake.Session("api")
+			if w := fresh.Window("server"); !w.Active || !w.Panes[1].Active {
+				t.Errorf("focus not restored to the second pane of server: %+v", fresh.Windows)
+			}
+
+			next := 0
+			for _, cmd := range fake.Commands() {
+				if next < len(tt.wantCalls) && cmd == tt.wantCalls[next] {
+					next++
+				}
+			}
+			if next < len(tt.wantCalls) {
+				t.Errorf("commands %q lack %q in order", fake.Commands(), tt.wantCalls[next:])
+			}
+		})
+	}
+}
+
+func TestRestartSessionOnlySessionRebuildFails(t *testing.T) {
+	t.Setenv(EnvTmux, "/tmp/tmux-1000/default,1,0")
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api", "editor", "server")
+	fake.Client = "api"
+	fake.Fail = map[string]string{"new-window": "no space for new window"}
+	c := NewClient(fake)
+
+	rebuild := func() error {
+		if err := c.NewSession("api", "/src/api"); err != nil {
+			return err
+		}
+		_, err := c.NewWindow("api", "server", "/src/api")
+		return err
+	}
+	if err := c.RestartSession("api", rebuild); err == nil {
+		t.Fatal("RestartSession() error = nil, want the rebuild's")
+	}
+
+	if len(fake.Sessions) != 1 || fake.Sessions[0].Name != "api" {
+		var names []string
+		for _, s := range fake.Sessions {
+			names = append(names, s.Name)
+		}
+		t.Fatalf("sessions = %q, want only the old api back under its name", names)
+	}
+	if w := fake.Session("api").Window("server"); w == nil {
+		t.Error("the session left is not the old one")
+	}
+	if fake.Client != "api" {
+		t.Errorf("client on %q, want api", fake.Client)
+	}
+}
@@ -1,53 +1,115 @@
 package tmux
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
 
 // NewSession creates a new detached session with a name and working directory.
-func NewSession(name, path string) error {
-	return RunSilent(CmdNewSession, FlagDetached, FlagSession, name, FlagDir, path)
+// env holds "NAME=value" pairs set in the session's environment before its
+// first shell starts.
+func (c *Client) NewSession(name, path string, env ...string) error {
+	args := []string{CmdNewSession, FlagDetached, FlagSession, name, FlagDir, path}
+	return c.RunSilent(append(args, envArgs(env)...)...)
+}
+
+// SetEnvironment sets variables in the session's environment, which the
+// windows and panes created in it from then on start with.
+func (c *Client) SetEnvironment(session string, env config.Env) error {
+	for _, pair := range env.Pairs() {
+		name, value, _ := strings.Cut(pair, EnvSep)
+		if err := c.RunSilent(CmdSetEnvironment, FlagTarget, session, name, value); err != nil {
+			return fmt.Errorf(ErrFmtSetEnv, name, err)
+		}
+	}
+	return nil
 }
 
 // KillSession kills the session with the given name.
-func KillSession(name string) error {
-	return RunSilent(CmdKillSession, FlagTarget, name)
+func (c *Client) KillSession(name string) error {
+	return c.RunSilent(CmdKillSession, FlagTarget, name)
 }
 
 // RenameSession renames a session.
-func RenameSession(oldName, newName string) error {
-	return RunSilent(CmdRenameSession, FlagTarget, oldName, newName)
+func (c *Client) RenameSession(oldName, newName string) error {
+	return c.RunSilent(CmdRenameSession, FlagTarget, oldName, newName)
 }
 
 // SwitchClient switches the current client to the given session.
-func SwitchClient(name string) error {
-	return RunSilent(CmdSwitchClient, FlagTarget, name)
+func (c *Client) SwitchClient(name string) error {
+	return c.RunSilent(CmdSwitchClient, FlagTarget, name)
 }
 
-// NewWindow creates a new window in the given session.
-func NewWindow(session, name string) error {
-	return RunSilent(CmdNewWindow, FlagTarget, session, FlagName, name)
+// AttachSession attaches the terminal to the session. With ExecRunner this
+// replaces the current process with tmux and only returns on failure.
+func (c *Client) AttachSession(name string) error {
+	args := []string{CmdAttachSession, FlagTarget, name}
+	if e, ok := c.runner.(execer); ok {
+		return e.Exec(args...)
+	}
+	return c.RunSilent(args...)
 }
 
-// KillWindow kills a specific window. Target format: "session:windowIndex".
-func KillWindow(target string) error {
-	return RunSilent(CmdKillWindow, FlagTarget, target)
+// SwitchOrAttach moves the user to the session: it switches the current client
+// when running inside tmux and attaches the terminal otherwise.
+func (c *Client) SwitchOrAttach(name string) error {
+	if InsideTmux() {
+		return c.SwitchClient(name)
+	}
+	return c.AttachSession(name)
+}
+
+// NewWindow creates a new window in the given session with its shell in dir
+// and returns the IDs of the window and its pane. env holds "NAME=value"
+// pairs for the window's shell.
+func (c *Client) NewWindow(session, name, dir string, env ...string) (WindowRef, error) {
+	args := []string{CmdNewWindow, FlagTarget, session, FlagName, name, FlagDir, dir, FlagPrintInfo, FlagFormat, WindowPaneIDFormat}
+	out, err := c.Run(append(args, envArgs(env)...)...)
+	if err != nil {
+		return WindowRef{}, err
+	}
+	return parseWindowRef(out)
+}
+
+// KillWindow kills a specific window. Target format: "session:windowIndex" or a window ID.
+func (c *Client) KillWindow(target string) error {
+	return c.RunSilent(CmdKillWindow, FlagTarget, target)
 }
 
 // RenameWindow renames the current window in a session.
-func RenameWindow(target, name string) error {
-	return RunSilent(CmdRenameWindow, FlagTarget, target, name)
+func (c *Client) RenameWindow(target, name string) error {
+	return c.RunSilent(CmdRenameWindow, FlagTarget, target, name)
 }
 
 // SendKeys sends keystrokes to a target pane.
-func SendKeys(target, keys string) error {
-	return RunSilent(CmdSendKeys, FlagTarget, target, keys, KeyEnter)
+func (c *Client) SendKeys(target, keys string) error {
+	return c.RunSilent(CmdSendKeys, FlagTarget, target, keys, KeyEnter)
+}
+
+// RespawnPane restarts the pane's shell in dir with env added to its
+// environment, killing whatever runs in it.
+func (c *Client) RespawnPane(target, dir string, env ...string) error {
+	args := []string{CmdRespawnPane, FlagKill, FlagTarget, target, FlagDir, dir}
+	return c.RunSilent(append(args, envArgs(env)...)...)
+}
+
+// envArgs turns "NAME=value" pairs into -e flags.
+func envArgs(env []string) []string {
+	args := make([]string, 0, 2*len(env))
+	for _, pair := range env {
+		args = append(args, FlagEnv, pair)
+	}
+	return args
 }
 
-// SelectWindow selects the first window in a session.
-func SelectWindow(target string) error {
-	return RunSilent(CmdSelectWindow, FlagTarget, target)
+// SelectWindow makes the target window the active one in its session.
+func (c *Client) SelectWindow(target string) error {
+	return c.RunSilent(CmdSelectWindow, FlagTarget, target)
 }
 
-// SelectPane selects a specific pane.
-func SelectPane(target string) error {
-	return RunSilent(CmdSelectPane, FlagTarget, fmt.Sprintf(FmtTargetPane0, target))
+// SelectPane makes the target pane the active one in its window.
+func (c *Client) SelectPane(target string) error {
+	return c.RunSilent(CmdSelectPane, FlagTarget, target)
 }
@@ -0,0 +1,30 @@
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// RunBeforeStart runs the project's before_start commands with the shell in
+// dir, in order, and stops at the first that exits non-zero. Their output is
+// only shown when one fails.
+func RunBeforeStart(dir string, commands []string) error {
+	for _, command := range commands {
+		if err := runShell(dir, command); err != nil {
+			return fmt.Errorf(ErrFmtBeforeStart, err)
+		}
+	}
+	return nil
+}
+
+// runShell runs command with the shell in dir, returning its output in the
+// error when it fails.
+func runShell(dir, command string) error {
+	cmd := exec.Command(Shell, ShellCommandFlag, command)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf(ErrFmtShellCommand, command, strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
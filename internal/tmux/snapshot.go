@@ -0,0 +1,156 @@
+package tmux
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+// idleCommands are shells; a pane running one of them has no command to save.
+var idleCommands = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true,
+	"ksh": true, "tcsh": true, "csh": true, "nu": true,
+}
+
+// Snapshot is a running session described as config.
+type Snapshot struct {
+	Path   string // the session's working directory, used as the project path
+	Layout config.Layout
+}
+
+// SnapshotSession inspects a live session and returns the layout that
+// recreates it: its windows, pane splits with sizes as percentages, and the
+// directory and command running in each pane.
+//
+// Layouts split each pane from the one created before it, so pane geometry
+// that can't be built that way, such as a row of panes above a full-width
+// pane, is approximated.
+func (c *Client) SnapshotSession(session string) (Snapshot, error) {
+	path, err := c.SessionPath(session)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	windows, err := c.ListWindows(session)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snap := Snapshot{Path: path}
+	for _, w := range windows {
+		panes, err := c.ListPanes(fmt.Sprintf(FmtSessionWindow, session, w.Index))
+		if err != nil {
+			return Snapshot{}, fmt.Errorf(ErrFmtReadWindow, w.Name, err)
+		}
+		root, layoutPanes := snapshotPanes(panes, path)
+		snap.Layout.Windows = append(snap.Layout.Windows, config.Window{
+			Name:  w.Name,
+			Root:  root,
+			Panes: layoutPanes,
+		})
+	}
+	return snap, nil
+}
+
+// region is a rectangle of cells within a window.
+type region struct {
+	left, top, width, height int
+}
+
+// snapshotPanes converts pane geometry into layout panes. Walking the panes
+// in order, each pane is carved off the start of the region the earlier panes
+// left; the next pane is split horizontally when the pane spans the region's
+// full height and vertically otherwise, and is sized as the share of the
+// region that remains. A window with a single idle pane needs no panes.
+//
+// It also returns the window's root, set when all its panes are in the same
+// directory other than the session's path; panes elsewhere get their own.
+func snapshotPanes(panes []PaneInfo, path string) (string, []config.Pane) {
+	if len(panes) == 0 {
+		return "", nil
+	}
+	windowDir := panes[0].Path
+	for _, p := range panes {
+		if p.Path != windowDir {
+			windowDir = path
+			break
+		}
+	}
+	root := relativeRoot(windowDir, path)
+	if len(panes) == 1 && runningCommand(panes[0]) == "" {
+		return root, nil
+	}
+
+	r := bounds(panes)
+	out := make([]config.Pane, len(panes))
+	for i, p := range panes {
+		out[i].Command = runningCommand(p)
+		if p.Path != windowDir {
+			out[i].Root = relativeRoot(p.Path, path)
+		}
+		if i == len(panes)-1 {
+			break
+		}
+
+		next := &out[i+1]
+		if p.Height == r.height {
+			rest := r.width - p.Width - 1 // one cell for the border
+			next.Split = SplitHorizontal
+			next.Size = percent(rest, r.width)
+			r = region{left: p.Left + p.Width + 1, top: r.top, width: rest, height: r.height}
+		} else {
+			rest := r.height - p.Height - 1
+			next.Split = SplitVertical
+			next.Size = percent(rest, r.height)
+			r = region{left: r.left, top: p.Top + p.Height + 1, width: r.width, height: rest}
+		}
+	}
+	return root, out
+}
+
+// bounds returns the smallest region holding all panes, which is the window.
+func bounds(panes []PaneInfo) region {
+	r := region{left: panes[0].Left, top: panes[0].Top}
+	right, bottom := 0, 0
+	for _, p := range panes {
+		r.left = min(r.left, p.Left)
+		r.top = min(r.top, p.Top)
+		right = max(right, p.Left+p.Width)
+		bottom = max(bottom, p.Top+p.Height)
+	}
+	r.width, r.height = right-r.left, bottom-r.top
+	return r
+}
+
+// percent formats part as a whole percentage of total, e.g. "30%". It rounds
+// up because tmux rounds split sizes down, so the percentage splits total
+// into the same number of cells again.
+func percent(part, total int) string {
+	if total <= 0 {
+		return ""
+	}
+	pct := int(math.Ceil(float64(part) * 100 / float64(total)))
+	return fmt.Sprintf(FmtSizePercent, min(max(pct, config.MinSizePercent), config.MaxSizePercent))
+}
+
+// runningCommand returns the program the pane runs, or "" for a shell.
+func runningCommand(p PaneInfo) string {
+	if idleCommands[p.Command] {
+		return ""
+	}
+	return p.Command
+}
+
+// relativeRoot returns dir as a root for a project at path: "" for path
+// itself, relative for directories below it and absolute otherwise.
+func relativeRoot(dir, path string) string {
+	if dir == "" || dir == path {
+		return ""
+	}
+	if rel, err := filepath.Rel(path, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return dir
+}
@@ -0,0 +1,48 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+func TestSnapshotSession(t *testing.T) {
+	fake := tmuxtest.New()
+	s := fake.AddSession("api", "/src/api", "editor", "server", "shell")
+
+	// editor: nvim on the left 70%, a shell on the right split 50/50 top and
+	// bottom, the bottom one in a subdirectory running tests.
+	editor := s.Windows[0]
+	editor.Panes = []*tmuxtest.Pane{
+		{ID: "%10", Command: "nvim", Path: "/src/api", Width: 56, Height: 24},
+		{ID: "%11", Path: "/src/api", Left: 57, Width: 23, Height: 12},
+		{ID: "%12", Command: "make", Path: "/src/api/web", Left: 57, Top: 13, Width: 23, Height: 11},
+	}
+	// server: a single pane running the server outside the project.
+	s.Windows[1].Panes[0].Command = "node"
+	s.Windows[1].Panes[0].Path = "/opt/app"
+	// shell: a single idle pane.
+	s.Windows[2].Panes[0].Path = "/src/api"
+
+	snap, err := NewClient(fake).SnapshotSession("api")
+	if err != nil {
+		t.Fatalf("SnapshotSession() error = %v", err)
+	}
+	want := Snapshot{
+		Path: "/src/api",
+		Layout: config.Layout{Windows: []config.Window{
+			{Name: "editor", Panes: []config.Pane{
+				{Command: "nvim"},
+				{Split: "horizontal", Size: "29%"},
+				{Split: "vertical", Size: "46%", Command: "make", Root: "web"},
+			}},
+			{Name: "server", Root: "/opt/app", Panes: []config.Pane{{Command: "node"}}},
+			{Name: "shell"},
+		}},
+	}
+	if !reflect.DeepEqual(snap, want) {
+		t.Errorf("SnapshotSession() =\n%+v\nwant\n%+v", snap, want)
+	}
+}
@@ -0,0 +1,89 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+// RunOnStop runs the project's on_stop steps against its session, in order,
+// and stops at the first that fails. Steps naming a window the session
+// doesn't have are skipped. Shell commands run in dir.
+func (c *Client) RunOnStop(session, dir string, steps []config.OnStop) error {
+	for i, step := range steps {
+		var err error
+		switch {
+		case step.Run != "":
+			err = runShell(dir, step.Run)
+		case step.Keys != "":
+			err = c.sendWindowKeys(session, step.Window, strings.Fields(step.Keys))
+		case step.Wait != "":
+			var timeout time.Duration
+			if timeout, err = time.ParseDuration(step.Wait); err != nil {
+				err = fmt.Errorf(ErrFmtParseWait, step.Wait, err)
+				break
+			}
+			err = c.waitForPanes(session, step.Window, timeout)
+		}
+		if err != nil {
+			return fmt.Errorf(ErrFmtRunOnStop, i+1, err)
+		}
+	}
+	return nil
+}
+
+// sendWindowKeys sends tmux key names to every pane of the named window.
+func (c *Client) sendWindowKeys(session, window string, keys []string) error {
+	panes, err := c.windowPanes(session, window)
+	if err != nil {
+		return err
+	}
+	for _, p := range panes {
+		args := append([]string{CmdSendKeys, FlagTarget, p.ID}, keys...)
+		if err := c.RunSilent(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForPanes waits until every pane of the named window is back at a shell,
+// or the window is gone. It gives up quietly after timeout; killing the
+// session is the fallback for whatever is still running.
+func (c *Client) waitForPanes(session, window string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		panes, err := c.windowPanes(session, window)
+		if err != nil {
+			return err
+		}
+		busy := false
+		for _, p := range panes {
+			if !idleCommands[p.Command] {
+				busy = true
+				break
+			}
+		}
+		if !busy || time.Now().After(deadline) {
+			return nil
+		}
+		time.Sleep(PollInterval)
+	}
+}
+
+// windowPanes returns the panes of the session's window with the given name,
+// or none if it has no such window.
+func (c *Client) windowPanes(session, window string) ([]PaneInfo, error) {
+	windows, err := c.ListWindows(session)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range windows {
+		if w.Name == window {
+			return c.ListPanes(fmt.Sprintf(FmtSessionWindow, session, w.Index))
+		}
+	}
+	return nil, nil
+}
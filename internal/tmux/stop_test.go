@@ -0,0 +1,84 @@
+package tmux
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+func TestRunOnStop(t *testing.T) {
+	dir := t.TempDir()
+	fake := tmuxtest.New()
+	s := fake.AddSession("api", dir, "editor", "server")
+	server := s.Window("server")
+	server.Panes = append(server.Panes, &tmuxtest.Pane{ID: "%90"})
+	server.Panes[0].Command = "docker-compose"
+	c := NewClient(fake)
+
+	start := time.Now()
+	err := c.RunOnStop("api", dir, []config.OnStop{
+		{Window: "server", Keys: "C-c"},
+		{Window: "server", Wait: "50ms"},
+		{Window: "missing", Keys: "C-c"},
+		{Run: "pwd > stopped"},
+	})
+	if err != nil {
+		t.Fatalf("RunOnStop() error = %v", err)
+	}
+
+	for _, p := range server.Panes {
+		if len(p.Keys) != 1 || p.Keys[0] != "C-c" {
+			t.Errorf("pane %s keys = %q, want C-c", p.ID, p.Keys)
+		}
+	}
+	if keys := s.Window("editor").Panes[0].Keys; len(keys) != 0 {
+		t.Errorf("editor got keys %q", keys)
+	}
+	// The busy pane never exits, so the wait runs out.
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("returned after %v, before the wait ran out", elapsed)
+	}
+	out, err := os.ReadFile(filepath.Join(dir, "stopped"))
+	if err != nil {
+		t.Fatalf("run step did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != dir {
+		t.Errorf("run step ran in %q, want %q", got, dir)
+	}
+}
+
+func TestRunOnStopWaitReturnsWhenPanesExit(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api", "server")
+	c := NewClient(fake)
+
+	start := time.Now()
+	if err := c.RunOnStop("api", "", []config.OnStop{{Window: "server", Wait: "10s"}}); err != nil {
+		t.Fatalf("RunOnStop() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waited %v on panes already at a shell", elapsed)
+	}
+}
+
+func TestRunOnStopStopsAtFailure(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api", "server")
+	c := NewClient(fake)
+
+	err := c.RunOnStop("api", t.TempDir(), []config.OnStop{
+		{Run: "echo boom >&2; exit 3"},
+		{Window: "server", Keys: "C-c"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "step 1") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("RunOnStop() error = %v, want step 1 failing with its output", err)
+	}
+	if keys := fake.Session("api").Window("server").Panes[0].Keys; len(keys) != 0 {
+		t.Errorf("steps after the failure ran: %q", keys)
+	}
+}
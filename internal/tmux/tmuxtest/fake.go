@@ -0,0 +1,695 @@
+// Package tmuxtest provides an in-memory tmux server for tests. Fake
+// implements tmux.Runner: it records every command it receives and simulates
+// the sessions, windows and panes those commands would create on a real server.
+package tmuxtest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Error messages mimicking the real tmux server.
+const (
+	errNoServer      = "no server running on /tmp/tmux-fake/default"
+	errNoClient      = "no current client"
+	errNoSession     = "can't find session: %s"
+	errNoWindow      = "can't find window: %s"
+	errNoPane        = "can't find pane: %s"
+	errDupSession    = "duplicate session: %s"
+	errUnknownCmd    = "unknown command: %s"
+	errMissingTarget = "missing target"
+)
+
+// defaultWindowName is the name tmux gives a window running the default shell.
+const defaultWindowName = "zsh"
+
+// valueFlags lists, per subcommand, the flags that take an argument. Any other
+// flag is treated as a boolean switch. A value flag given more than once keeps
+// every value, one per line.
+var valueFlags = map[string]string{
+	"new-session":     "scnFxyet",
+	"new-window":      "tcnFe",
+	"split-window":    "tclpFe",
+	"kill-session":    "t",
+	"kill-window":     "t",
+	"rename-session":  "t",
+	"rename-window":   "t",
+	"switch-client":   "t",
+	"attach-session":  "t",
+	"send-keys":       "t",
+	"select-window":   "t",
+	"select-pane":     "t",
+	"select-layout":   "t",
+	"list-sessions":   "Ff",
+	"list-windows":    "tFf",
+	"display-message": "tFc",
+	"has-session":     "t",
+	"capture-pane":    "tSEb",
+	"list-panes":      "tFf",
+	"set-environment": "t",
+	"respawn-pane":    "tce",
+}
+
+var formatVar = regexp.MustCompile(`#\{([a-z_]+)\}`)
+
+// Session is a simulated tmux session.
+type Session struct {
+	ID      string
+	Name    string
+	Path    string
+	Env     map[string]string // set by new-session -e and set-environment
+	Windows []*Window
+}
+
+// Window is a simulated tmux window.
+type Window struct {
+	ID     string
+	Index  int
+	Name   string
+	Active bool
+	Layout string // set by select-layout
+	Panes  []*Pane
+}
+
+// Pane is a simulated tmux pane.
+type Pane struct {
+	ID      string
+	Active  bool
+	Path    string
+	Split   string            // "-h" or "-v" for panes created by split-window
+	Size    string            // size argument given to split-window, if any
+	Keys    []string          // each send-keys call, joined with spaces
+	Content string            // what capture-pane prints for the pane
+	Command string            // foreground process; "" reports the default shell
+	Env     map[string]string // -e variables the pane's shell was started with
+	// Position and size in cells, as reported by list-panes. The fake does
+	// not lay panes out; tests set these directly.
+	Left, Top, Width, Height int
+}
+
+// Fake is an in-memory tmux server. The zero value is an empty server with
+// no client attached.
+type Fake struct {
+	// Calls records the arguments of every command run, in order.
+	Calls [][]string
+	// Sessions holds the simulated server state.
+	Sessions []*Session
+	// Client is the session the simulated client is attached to, or "" if
+	// the commands are run from outside tmux.
+	Client string
+	// Fail makes every command with the given subcommand name fail with the
+	// mapped error message.
+	Fail map[string]string
+	// BaseIndex and PaneBaseIndex mirror the tmux options of the same name:
+	// the index of the first window in a session and of the first pane in
+	// a window.
+	BaseIndex, PaneBaseIndex int
+
+	nextSession, nextWindow, nextPane int
+}
+
+// New returns an empty fake server.
+func New() *Fake {
+	return &Fake{}
+}
+
+// Commands returns the recorded calls as space-joined strings, which is
+// convenient for table-driven comparisons.
+func (f *Fake) Commands() []string {
+	out := make([]string, len(f.Calls))
+	for i, c := range f.Calls {
+		out[i] = strings.Join(c, " ")
+	}
+	return out
+}
+
+// Reset clears the recorded calls but keeps the server state.
+func (f *Fake) Reset() {
+	f.Calls = nil
+}
+
+// AddSession creates a session directly, bypassing the command log, with one
+// window per name given. It returns the new session.
+func (f *Fake) AddSession(name, path string, windows ...string) *Session {
+	if len(windows) == 0 {
+		windows = []string{defaultWindowName}
+	}
+	s := &Session{ID: f.newID("$", &f.nextSession), Name: name, Path: path}
+	for i, w := range windows {
+		s.Windows = append(s.Windows, f.newWindow(f.BaseIndex+i, w, path))
+	}
+	s.Windows[0].Active = true
+	f.Sessions = append(f.Sessions, s)
+	return s
+}
+
+// Session returns the session with the given name, or nil.
+func (f *Fake) Session(name string) *Session {
+	for _, s := range f.Sessions {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Window returns the window with the given name, or nil.
+func (s *Session) Window(name string) *Window {
+	for _, w := range s.Windows {
+		if w.Name == name {
+			return w
+		}
+	}
+	return nil
+}
+
+// Run implements tmux.Runner.
+func (f *Fake) Run(args ...string) (string, error) {
+	f.Calls = append(f.Calls, append([]string(nil), args...))
+	if len(args) == 0 {
+		return "", fmt.Errorf(errUnknownCmd, "")
+	}
+
+	name := args[0]
+	if msg, ok := f.Fail[name]; ok {
+		return "", fmt.Errorf("%s", msg)
+	}
+	spec, ok := valueFlags[name]
+	if !ok {
+		return "", fmt.Errorf(errUnknownCmd, name)
+	}
+	flags, pos := parseFlags(args[1:], spec)
+
+	switch name {
+	case "new-session":
+		return f.newSession(flags)
+	case "kill-session":
+		s, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		f.removeSession(s)
+		return "", nil
+	case "rename-session":
+		s, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if f.Client == s.Name {
+			f.Client = first(pos)
+		}
+		s.Name = first(pos)
+		return "", nil
+	case "switch-client":
+		if f.Client == "" {
+			return "", fmt.Errorf(errNoClient)
+		}
+		s, w, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if w != nil {
+			s.selectWindow(w)
+		}
+		f.Client = s.Name
+		return "", nil
+	case "attach-session":
+		s, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		f.Client = s.Name
+		return "", nil
+	case "new-window":
+		return f.newWindowCmd(flags)
+	case "kill-window":
+		s, w, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		s.removeWindow(w)
+		if len(s.Windows) == 0 {
+			f.removeSession(s)
+		}
+		return "", nil
+	case "rename-window":
+		_, w, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		w.Name = first(pos)
+		return "", nil
+	case "send-keys":
+		_, _, p, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		p.Keys = append(p.Keys, strings.Join(pos, " "))
+		return "", nil
+	case "select-window":
+		s, w, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		s.selectWindow(w)
+		return "", nil
+	case "select-pane":
+		_, w, p, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		w.selectPane(p)
+		return "", nil
+	case "select-layout":
+		_, w, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		w.Layout = first(pos)
+		return "", nil
+	case "split-window":
+		return f.splitWindow(flags)
+	case "list-sessions":
+		if len(f.Sessions) == 0 {
+			return "", fmt.Errorf(errNoServer)
+		}
+		lines := make([]string, 0, len(f.Sessions))
+		for _, s := range f.Sessions {
+			lines = append(lines, expand(flags["F"], f.vars(s, nil, nil)))
+		}
+		return strings.Join(lines, "\n"), nil
+	case "list-windows":
+		s, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		lines := make([]string, 0, len(s.Windows))
+		for _, w := range s.Windows {
+			lines = append(lines, expand(flags["F"], f.vars(s, w, nil)))
+		}
+		return strings.Join(lines, "\n"), nil
+	case "display-message":
+		target := flags["t"]
+		if target == "" {
+			if f.Client == "" {
+				return "", fmt.Errorf(errNoClient)
+			}
+			target = f.Client
+		}
+		s, w, p, err := f.resolve(target)
+		if err != nil {
+			return "", err
+		}
+		format := first(pos)
+		if format == "" {
+			format = flags["F"]
+		}
+		return expand(format, f.vars(s, w, p)), nil
+	case "has-session":
+		if len(f.Sessions) == 0 {
+			return "", fmt.Errorf(errNoServer)
+		}
+		_, err := f.findSession(flags["t"])
+		return "", err
+	case "list-panes":
+		s, w, _, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		lines := make([]string, 0, len(w.Panes))
+		for _, p := range w.Panes {
+			lines = append(lines, expand(flags["F"], f.vars(s, w, p)))
+		}
+		return strings.Join(lines, "\n"), nil
+	case "set-environment":
+		s, err := f.findSession(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		if len(pos) != 2 {
+			return "", fmt.Errorf(errMissingTarget)
+		}
+		if s.Env == nil {
+			s.Env = make(map[string]string)
+		}
+		s.Env[pos[0]] = pos[1]
+		return "", nil
+	case "respawn-pane":
+		_, _, p, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		p.Path, p.Env, p.Keys = flags["c"], envFlags(flags), nil
+		return "", nil
+	case "capture-pane":
+		_, _, p, err := f.resolve(flags["t"])
+		if err != nil {
+			return "", err
+		}
+		return p.Content, nil
+	}
+	return "", fmt.Errorf(errUnknownCmd, name)
+}
+
+func (f *Fake) newSession(flags map[string]string) (string, error) {
+	name := flags["s"]
+	if name == "" {
+		name = strconv.Itoa(f.nextSession)
+	}
+	if f.Session(name) != nil {
+		return "", fmt.Errorf(errDupSession, name)
+	}
+	window := flags["n"]
+	if window == "" {
+		window = defaultWindowName
+	}
+	s := f.AddSession(name, flags["c"], window)
+	s.Env = envFlags(flags)
+	if _, ok := flags["P"]; ok {
+		return expand(printFormat(flags), f.vars(s, s.Windows[0], s.Windows[0].Panes[0])), nil
+	}
+	return "", nil
+}
+
+func (f *Fake) newWindowCmd(flags map[string]string) (string, error) {
+	s, err := f.findSession(flags["t"])
+	if err != nil {
+		return "", err
+	}
+	name := flags["n"]
+	if name == "" {
+		name = defaultWindowName
+	}
+	next := f.BaseIndex
+	if n := len(s.Windows); n > 0 {
+		next = s.Windows[n-1].Index + 1
+	}
+	w := f.newWindow(next, name, flags["c"])
+	w.Panes[0].Env = envFlags(flags)
+	s.Windows = append(s.Windows, w)
+	if _, detached := flags["d"]; !detached {
+		s.selectWindow(w)
+	}
+	if _, ok := flags["P"]; ok {
+		return expand(printFormat(flags), f.vars(s, w, w.Panes[0])), nil
+	}
+	return "", nil
+}
+
+func (f *Fake) splitWindow(flags map[string]string) (string, error) {
+	s, w, target, err := f.resolve(flags["t"])
+	if err != nil {
+		return "", err
+	}
+	p := &Pane{ID: f.newID("%", &f.nextPane), Path: flags["c"], Split: "-v", Env: envFlags(flags)}
+	if _, ok := flags["h"]; ok {
+		p.Split = "-h"
+	}
+	if size, ok := flags["p"]; ok {
+		p.Size = size
+	} else if size, ok := flags["l"]; ok {
+		p.Size = size
+	}
+
+	at := w.paneIndex(target) + 1
+	w.Panes = append(w.Panes[:at], append([]*Pane{p}, w.Panes[at:]...)...)
+	if _, detached := flags["d"]; !detached {
+		w.selectPane(p)
+	}
+	if _, ok := flags["P"]; ok {
+		return expand(printFormat(flags), f.vars(s, w, p)), nil
+	}
+	return "", nil
+}
+
+func (f *Fake) newWindow(index int, name, path string) *Window {
+	w := &Window{ID: f.newID("@", &f.nextWindow), Index: index, Name: name}
+	w.Panes = []*Pane{{ID: f.newID("%", &f.nextPane), Active: true, Path: path}}
+	return w
+}
+
+func (f *Fake) newID(prefix string, counter *int) string {
+	id := prefix + strconv.Itoa(*counter)
+	*counter++
+	return id
+}
+
+func (f *Fake) removeSession(s *Session) {
+	for i, cur := range f.Sessions {
+		if cur == s {
+			f.Sessions = append(f.Sessions[:i], f.Sessions[i+1:]...)
+			break
+		}
+	}
+	if f.Client == s.Name {
+		f.Client = ""
+	}
+}
+
+func (f *Fake) findSession(name string) (*Session, error) {
+	if name == "" {
+		if f.Client == "" {
+			return nil, fmt.Errorf(errMissingTarget)
+		}
+		name = f.Client
+	}
+	name = strings.TrimSuffix(name, ":")
+	if len(f.Sessions) == 0 {
+		return nil, fmt.Errorf(errNoServer)
+	}
+	for _, s := range f.Sessions {
+		if s.Name == name || s.ID == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf(errNoSession, name)
+}
+
+// resolve parses a tmux target ("session", "session:window", "session:window.pane",
+// "@window", "%pane") and returns the addressed objects. Window and pane
+// default to the active ones when not given.
+func (f *Fake) resolve(target string) (*Session, *Window, *Pane, error) {
+	if strings.HasPrefix(target, "%") || strings.HasPrefix(target, "@") {
+		for _, s := range f.Sessions {
+			for _, w := range s.Windows {
+				if w.ID == target {
+					return s, w, w.activePane(), nil
+				}
+				for _, p := range w.Panes {
+					if p.ID == target {
+						return s, w, p, nil
+					}
+				}
+			}
+		}
+		return nil, nil, nil, fmt.Errorf(errNoPane, target)
+	}
+
+	sessName, rest, hasWindow := strings.Cut(target, ":")
+	if target == "" && f.Client != "" {
+		sessName = f.Client
+	}
+	s, err := f.findSession(sessName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !hasWindow || rest == "" {
+		w := s.activeWindow()
+		return s, w, w.activePane(), nil
+	}
+
+	winSpec, paneSpec, hasPane := strings.Cut(rest, ".")
+	w := s.findWindow(winSpec)
+	if w == nil {
+		return nil, nil, nil, fmt.Errorf(errNoWindow, target)
+	}
+	if !hasPane {
+		return s, w, w.activePane(), nil
+	}
+	p := w.findPane(paneSpec, f.PaneBaseIndex)
+	if p == nil {
+		return nil, nil, nil, fmt.Errorf(errNoPane, target)
+	}
+	return s, w, p, nil
+}
+
+func (s *Session) activeWindow() *Window {
+	for _, w := range s.Windows {
+		if w.Active {
+			return w
+		}
+	}
+	return s.Windows[0]
+}
+
+func (s *Session) findWindow(spec string) *Window {
+	for _, w := range s.Windows {
+		if w.ID == spec || strconv.Itoa(w.Index) == spec {
+			return w
+		}
+	}
+	return s.Window(spec)
+}
+
+func (s *Session) selectWindow(w *Window) {
+	for _, cur := range s.Windows {
+		cur.Active = cur == w
+	}
+}
+
+func (s *Session) removeWindow(w *Window) {
+	for i, cur := range s.Windows {
+		if cur == w {
+			s.Windows = append(s.Windows[:i], s.Windows[i+1:]...)
+			break
+		}
+	}
+	if w.Active && len(s.Windows) > 0 {
+		s.Windows[0].Active = true
+	}
+}
+
+func (w *Window) activePane() *Pane {
+	for _, p := range w.Panes {
+		if p.Active {
+			return p
+		}
+	}
+	return w.Panes[0]
+}
+
+func (w *Window) findPane(spec string, base int) *Pane {
+	for i, p := range w.Panes {
+		if p.ID == spec || strconv.Itoa(base+i) == spec {
+			return p
+		}
+	}
+	return nil
+}
+
+func (w *Window) paneIndex(p *Pane) int {
+	for i, cur := range w.Panes {
+		if cur == p {
+			return i
+		}
+	}
+	return len(w.Panes) - 1
+}
+
+func (w *Window) selectPane(p *Pane) {
+	for _, cur := range w.Panes {
+		cur.Active = cur == p
+	}
+}
+
+// vars returns the format variables for the given objects; w and p may be nil.
+func (f *Fake) vars(s *Session, w *Window, p *Pane) map[string]string {
+	v := map[string]string{
+		"session_id":       s.ID,
+		"session_name":     s.Name,
+		"session_path":     s.Path,
+		"session_windows":  strconv.Itoa(len(s.Windows)),
+		"session_attached": boolFlag(f.Client == s.Name),
+	}
+	if w != nil {
+		v["window_id"] = w.ID
+		v["window_index"] = strconv.Itoa(w.Index)
+		v["window_name"] = w.Name
+		v["window_active"] = boolFlag(w.Active)
+		v["window_panes"] = strconv.Itoa(len(w.Panes))
+	}
+	if p != nil {
+		v["pane_id"] = p.ID
+		v["pane_index"] = strconv.Itoa(f.PaneBaseIndex + w.paneIndex(p))
+		v["pane_active"] = boolFlag(p.Active)
+		v["pane_current_path"] = p.Path
+		v["pane_current_command"] = p.Command
+		if p.Command == "" {
+			v["pane_current_command"] = defaultWindowName
+		}
+		v["pane_left"] = strconv.Itoa(p.Left)
+		v["pane_top"] = strconv.Itoa(p.Top)
+		v["pane_width"] = strconv.Itoa(p.Width)
+		v["pane_height"] = strconv.Itoa(p.Height)
+	}
+	return v
+}
+
+// parseFlags splits tmux arguments into flags and positional arguments.
+// Flags listed in spec consume the next argument as their value.
+func parseFlags(args []string, spec string) (map[string]string, []string) {
+	flags := make(map[string]string)
+	var pos []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if len(pos) > 0 || len(a) < 2 || a[0] != '-' {
+			pos = append(pos, a)
+			continue
+		}
+		for j := 1; j < len(a); j++ {
+			flag := string(a[j])
+			if !strings.Contains(spec, flag) {
+				flags[flag] = ""
+				continue
+			}
+			value := ""
+			if j+1 < len(a) {
+				value = a[j+1:]
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if prev, repeated := flags[flag]; repeated {
+				value = prev + "\n" + value
+			}
+			flags[flag] = value
+			break
+		}
+	}
+	return flags, pos
+}
+
+// envFlags returns the NAME=value pairs given with -e, or nil.
+func envFlags(flags map[string]string) map[string]string {
+	pairs, ok := flags["e"]
+	if !ok {
+		return nil
+	}
+	env := make(map[string]string)
+	for _, pair := range strings.Split(pairs, "\n") {
+		name, value, _ := strings.Cut(pair, "=")
+		env[name] = value
+	}
+	return env
+}
+
+// printFormat returns the -F format for commands run with -P, defaulting to
+// the pane target tmux prints.
+func printFormat(flags map[string]string) string {
+	if format, ok := flags["F"]; ok {
+		return format
+	}
+	return "#{session_name}:#{window_index}.#{pane_index}"
+}
+
+func expand(format string, vars map[string]string) string {
+	return formatVar.ReplaceAllStringFunc(format, func(m string) string {
+		return vars[formatVar.FindStringSubmatch(m)[1]]
+	})
+}
+
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func first(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
@@ -0,0 +1,113 @@
+package tmuxtest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// tmuxBin is the tmux binary the integration harness runs.
+const tmuxBin = "tmux"
+
+// socketPrefix prefixes the private socket name of every test server.
+const socketPrefix = "tplm-test-"
+
+// Server is a private tmux server started on its own socket for one test,
+// so integration tests never touch the user's sessions.
+type Server struct {
+	// SocketName is the -L name the server listens on.
+	SocketName string
+	t          testing.TB
+}
+
+// StartServer starts an isolated tmux server that ignores the user's
+// tmux.conf and kills it when the test ends. Options are applied as global
+// settings ("base-index 1") before the test runs. The test is skipped when
+// tmux is not installed.
+func StartServer(t testing.TB, options ...string) *Server {
+	t.Helper()
+	if _, err := exec.LookPath(tmuxBin); err != nil {
+		t.Skip("tmux not installed")
+	}
+	// Commands must target the private socket, never the session the tests
+	// happen to be running in.
+	t.Setenv("TMUX", "")
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{SocketName: socketPrefix + hex.EncodeToString(suffix), t: t}
+
+	args := []string{"-f", "/dev/null", "start-server", ";", "set-option", "-g", "exit-empty", "off",
+		";", "set-option", "-g", "default-shell", "/bin/sh"}
+	for _, opt := range options {
+		args = append(args, ";", "set-option", "-g")
+		args = append(args, strings.Fields(opt)...)
+	}
+	s.Run(args...)
+	t.Cleanup(func() {
+		_ = exec.Command(tmuxBin, "-L", s.SocketName, "kill-server").Run()
+	})
+	return s
+}
+
+// Run executes a tmux command against the server and fails the test on error.
+func (s *Server) Run(args ...string) string {
+	s.t.Helper()
+	cmd := exec.Command(tmuxBin, append([]string{"-L", s.SocketName}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		s.t.Fatalf("tmux %s: %v: %s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimRight(stdout.String(), "\n")
+}
+
+// PaneState is one row of list-panes output.
+type PaneState struct {
+	WindowName string
+	Index      int
+	Left, Top  int
+	Width      int
+	Height     int
+	Path       string
+}
+
+// Panes lists every pane of the session, in window and pane order.
+func (s *Server) Panes(session string) []PaneState {
+	s.t.Helper()
+	out := s.Run("list-panes", "-s", "-t", session, "-F",
+		"#{window_name}\t#{pane_index}\t#{pane_left}\t#{pane_top}\t#{pane_width}\t#{pane_height}\t#{pane_current_path}")
+	var panes []PaneState
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) != 7 {
+			s.t.Fatalf("unexpected list-panes line %q", line)
+		}
+		panes = append(panes, PaneState{
+			WindowName: f[0],
+			Index:      atoi(s.t, f[1]),
+			Left:       atoi(s.t, f[2]),
+			Top:        atoi(s.t, f[3]),
+			Width:      atoi(s.t, f[4]),
+			Height:     atoi(s.t, f[5]),
+			Path:       f[6],
+		})
+	}
+	return panes
+}
+
+func atoi(t testing.TB, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatalf("parsing list-panes field: %v", err)
+	}
+	return n
+}
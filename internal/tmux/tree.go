@@ -0,0 +1,78 @@
+package tmux
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+// treePlan returns the splits that build a pane tree. A node's area is first
+// divided between its children, each split carving the next child off the
+// pane left for the rest; then every child is built inside its own pane.
+// Panes are indexed by their depth-first position among the tree's panes,
+// so the tree's first pane is the window's first pane.
+func treePlan(root config.PaneNode) []paneStep {
+	var steps []paneStep
+	var build func(n config.PaneNode, first int)
+	build = func(n config.PaneNode, first int) {
+		shares := childShares(n.Children)
+		start := first
+		for i, child := range n.Children {
+			next := start + child.LeafCount()
+			if i+1 < len(n.Children) {
+				steps = append(steps, paneStep{
+					Target:  start,
+					Leaf:    next,
+					Split:   n.Split,
+					Percent: strconv.Itoa(restPercent(shares[i:])),
+				})
+			}
+			build(child, start)
+			start = next
+		}
+	}
+	build(root, 0)
+	return steps
+}
+
+// childShares returns the share of its parent's area each child takes.
+// Children without a size split what the sized ones leave evenly.
+func childShares(children []config.PaneNode) []float64 {
+	shares := make([]float64, len(children))
+	sized, unsized := 0.0, 0
+	for i, child := range children {
+		pct, err := strconv.Atoi(strings.TrimSuffix(child.Size, SizeSuffix))
+		if err != nil || pct < config.MinSizePercent {
+			unsized++
+			continue
+		}
+		shares[i] = float64(pct)
+		sized += shares[i]
+	}
+	if unsized == 0 {
+		return shares
+	}
+	even := max((config.MaxSizePercent-sized)/float64(unsized), config.MinSizePercent)
+	for i := range shares {
+		if shares[i] == 0 {
+			shares[i] = even
+		}
+	}
+	return shares
+}
+
+// restPercent returns how much of a pane holding shares the pane split off
+// for all but the first of them takes, in percent.
+func restPercent(shares []float64) int {
+	total, rest := 0.0, 0.0
+	for i, share := range shares {
+		total += share
+		if i > 0 {
+			rest += share
+		}
+	}
+	pct := int(math.Round(rest / total * 100))
+	return min(max(pct, config.MinSizePercent), config.MaxSizePercent-1)
+}
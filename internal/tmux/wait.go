@@ -0,0 +1,105 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+)
+
+// waitFor blocks until the condition holds, checking every PollInterval, and
+// fails once its timeout passes. Output is matched against what pane printed
+// after command was sent, files are looked up relative to dir.
+func (c *Client) waitFor(w *config.WaitFor, pane, command, dir string) error {
+	if w.Delay != "" {
+		d, err := time.ParseDuration(w.Delay)
+		if err != nil {
+			return fmt.Errorf(ErrFmtWaitInvalid, err)
+		}
+		time.Sleep(d)
+		return nil
+	}
+
+	timeout := config.DefaultWaitTimeout
+	if w.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(w.Timeout); err != nil {
+			return fmt.Errorf(ErrFmtWaitInvalid, err)
+		}
+	}
+
+	var (
+		met  func() bool
+		what string
+	)
+	switch {
+	case w.Port != "":
+		addr := w.Port
+		if !strings.Contains(addr, ":") {
+			addr = net.JoinHostPort(DefaultPortHost, addr)
+		}
+		met, what = func() bool { return portOpen(addr) }, fmt.Sprintf(FmtWaitPort, addr)
+	case w.File != "":
+		path := w.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		met, what = func() bool { return fileExists(path) }, fmt.Sprintf(FmtWaitFile, path)
+	case w.Output != "":
+		re, err := regexp.Compile(w.Output)
+		if err != nil {
+			return fmt.Errorf(ErrFmtWaitInvalid, err)
+		}
+		met = func() bool {
+			out, err := c.Run(CmdCapturePane, FlagPrint, FlagJoin, FlagStart, HistoryStart, FlagTarget, pane)
+			return err == nil && re.MatchString(afterCommand(out, command))
+		}
+		what = fmt.Sprintf(FmtWaitOutput, w.Output)
+	default:
+		return fmt.Errorf(ErrFmtWaitInvalid, errors.New(ErrWaitNoCondition))
+	}
+
+	deadline := time.Now().Add(timeout)
+	for !met() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf(ErrFmtWaitTimeout, timeout, what)
+		}
+		time.Sleep(PollInterval)
+	}
+	return nil
+}
+
+// afterCommand returns the lines of out after the last one echoing command,
+// that is ending with it, so a pattern that appears in the command itself
+// doesn't match at once. Before the shell echoes the command, out is
+// returned whole.
+func afterCommand(out, command string) string {
+	lines := strings.Split(out, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasSuffix(strings.TrimRight(lines[i], " "), command) {
+			return strings.Join(lines[i+1:], "\n")
+		}
+	}
+	return out
+}
+
+// portOpen reports whether addr accepts TCP connections.
+func portOpen(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, PollInterval)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,109 @@
+package tmux
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+func TestRunOnStartWaitFor(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ready"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	open := ln.Addr().String()
+	// A port nothing listens on any more.
+	closedLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := closedLn.Addr().String()
+	closedLn.Close()
+
+	tests := []struct {
+		name    string
+		wait    config.WaitFor
+		content string // pane output; the postgres log line by default
+		wantErr string
+	}{
+		{name: "open port", wait: config.WaitFor{Port: open}},
+		{name: "existing file", wait: config.WaitFor{File: "ready"}},
+		{name: "matching output", wait: config.WaitFor{Output: `ready to accept \w+`}},
+		{
+			name:    "output after the echoed command",
+			wait:    config.WaitFor{Output: `ready to accept \w+`},
+			content: "$ postgres\nLOG:  database system is ready to accept connections\n",
+		},
+		{name: "delay", wait: config.WaitFor{Delay: "10ms"}},
+		{
+			name:    "closed port times out",
+			wait:    config.WaitFor{Port: closed, Timeout: "50ms"},
+			wantErr: `on_start for window "db": timed out after 50ms waiting for port ` + closed,
+		},
+		{
+			name:    "missing file times out",
+			wait:    config.WaitFor{File: "never", Timeout: "50ms"},
+			wantErr: "timed out after 50ms waiting for file " + filepath.Join(dir, "never"),
+		},
+		{
+			name:    "output times out",
+			wait:    config.WaitFor{Output: "FATAL", Timeout: "50ms"},
+			wantErr: `timed out after 50ms waiting for output matching "FATAL"`,
+		},
+		{
+			name:    "echoed command does not match",
+			wait:    config.WaitFor{Output: "postgres", Timeout: "50ms"},
+			content: "$ postgres\nLOG:  starting\n",
+			wantErr: `waiting for output matching "postgres"`,
+		},
+		{name: "no condition", wait: config.WaitFor{Timeout: "1s"}, wantErr: "sets none of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := tmuxtest.New()
+			s := fake.AddSession("api", dir, "db", "server")
+			content := tt.content
+			if content == "" {
+				content = "LOG:  database system is ready to accept connections\n"
+			}
+			s.Window("db").Panes[0].Content = content
+
+			windows := []WindowRef{
+				{Name: "db", ID: "@0", Panes: []string{"%0"}},
+				{Name: "server", ID: "@1", Panes: []string{"%1"}},
+			}
+			commands := []config.OnStart{
+				{Window: "db", Command: "postgres", WaitFor: &tt.wait},
+				{Window: "server", Command: "make migrate"},
+			}
+			err := NewClient(fake).RunOnStart(windows, commands, dir)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("RunOnStart() error = %v", err)
+				}
+				if keys := s.Window("server").Panes[0].Keys; len(keys) != 1 {
+					t.Errorf("server keys = %q, want make migrate after the wait", keys)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("RunOnStart() error = %v, want %q", err, tt.wantErr)
+			}
+			if keys := s.Window("server").Panes[0].Keys; len(keys) != 0 {
+				t.Errorf("server got %q although the wait before it failed", keys)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ const (
 	ColorText      = "252"
 	ColorGreen     = "42"
 	ColorRed       = "196"
+	ColorMatch     = "214"
 )
 
 // UI symbols.
@@ -32,13 +33,33 @@ const (
 const (
 	MsgNoProjects  = "(no projects configured)"
 	MsgNoSessions  = "(no active sessions)"
-	MsgHelpBar     = "hjkl navigate  ⏎ select  d kill  r rename  q quit"
+	MsgNoMatches   = "(no matches)"
+	MsgHelpBar     = "hjkl navigate  ⏎ select  / filter  a apply  R restart  d kill  r rename  q quit"
+	MsgMoreAbove   = "↑ %d more"
+	MsgMoreBelow   = "↓ %d more"
 	MsgConfirmKill = "  Kill %s %q? (y/n)"
 	MsgError       = "  Error: %v"
+	MsgStarting    = "  Starting %s…"
+	MsgApplying    = "  Applying %s…"
+	MsgRestarting  = "  Restarting %s…"
+	MsgStopping    = "  Stopping %s…"
 	MsgSession     = "session"
 	MsgWindow      = "window"
 )
 
+// Preview messages.
+const (
+	MsgPreviewError        = "(preview unavailable: %v)"
+	MsgPreviewNotRunning   = "not running; opening it creates:"
+	MsgPreviewPane         = "pane %d"
+	MsgPreviewDefaultSplit = "horizontal"
+	MsgPreviewOnStart      = "on start: %s"
+	MsgPreviewLayout       = "layout: %s"
+)
+
+// Error message templates.
+const ErrFmtOtherServer = "session %q lives on tmux server %q; attach to that server to switch to it"
+
 // Rename input settings.
 const (
 	RenameCharLimit = 64
@@ -46,6 +67,13 @@ const (
 	RenamePrompt    = "Rename: "
 )
 
+// Filter input settings.
+const (
+	FilterCharLimit = 64
+	FilterWidth     = 40
+	FilterPrompt    = "/"
+)
+
 // Key names for rename input handling.
 const (
 	KeyEnter = "enter"
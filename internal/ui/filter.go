@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// newFilterInput creates the text input used by the picker's filter mode.
+func newFilterInput() textinput.Model {
+	ti := textinput.New()
+	ti.CharLimit = FilterCharLimit
+	ti.Width = FilterWidth
+	ti.Prompt = FilterPrompt
+	ti.PromptStyle = inputPromptStyle
+	return ti
+}
+
+// scoredGroup is a run of display items that are ranked and kept together:
+// a project on its own, or a session followed by its expanded windows.
+type scoredGroup struct {
+	items []pickerItem
+	score int
+}
+
+// filterItems fuzzy-filters a flattened display list, recording the matched
+// rune positions on each kept item. Projects match on name or path. A session
+// is kept when its name matches, with all of its expanded windows, or when any
+// of its expanded windows match, with only those windows. Within each section,
+// results are ordered by descending score.
+func filterItems(items []pickerItem, query string) []pickerItem {
+	var projects, sessions []scoredGroup
+
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+
+		if !item.isSession && !item.isWindow {
+			nameScore, nameMatches, nameOK := fuzzyMatch(query, item.name)
+			pathScore, pathMatches, pathOK := fuzzyMatch(query, item.path)
+			if !nameOK && !pathOK {
+				continue
+			}
+			item.matches, item.pathMatches = nameMatches, pathMatches
+			score := nameScore
+			if !nameOK || (pathOK && pathScore > nameScore) {
+				score = pathScore
+			}
+			projects = append(projects, scoredGroup{items: []pickerItem{item}, score: score})
+			continue
+		}
+
+		if item.isWindow {
+			continue // Windows are consumed with their session below.
+		}
+
+		score, matches, sessionOK := fuzzyMatch(query, item.name)
+		item.matches = matches
+		group := scoredGroup{items: []pickerItem{item}, score: score}
+		for i+1 < len(items) && items[i+1].isWindow {
+			i++
+			win := items[i]
+			winScore, winMatches, winOK := fuzzyMatch(query, win.name)
+			if !winOK && !sessionOK {
+				continue
+			}
+			win.matches = winMatches
+			group.items = append(group.items, win)
+			if winOK && (!sessionOK || winScore > group.score) {
+				group.score = winScore
+			}
+		}
+		if sessionOK || len(group.items) > 1 {
+			sessions = append(sessions, group)
+		}
+	}
+
+	out := make([]pickerItem, 0, len(items))
+	for _, groups := range [][]scoredGroup{projects, sessions} {
+		sort.SliceStable(groups, func(a, b int) bool { return groups[a].score > groups[b].score })
+		for _, g := range groups {
+			out = append(out, g.items...)
+		}
+	}
+	return out
+}
+
+// highlight renders text with style, emphasizing the runes at the matched
+// positions. The style's left padding is applied once to the whole text.
+func highlight(text string, matches []int, style lipgloss.Style) string {
+	if len(matches) == 0 {
+		return style.Render(text)
+	}
+
+	matched := make(map[int]bool, len(matches))
+	for _, pos := range matches {
+		matched[pos] = true
+	}
+
+	base := style.UnsetPaddingLeft()
+	emphasized := matchStyle.Inherit(base)
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", style.GetPaddingLeft()))
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && matched[j] == matched[i] {
+			j++
+		}
+		segment := base
+		if matched[i] {
+			segment = emphasized
+		}
+		b.WriteString(segment.Render(string(runes[i:j])))
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fuzzy match scoring weights.
+const (
+	scoreMatch       = 1
+	scoreConsecutive = 5
+	scoreBoundary    = 3
+	penaltyGap       = 1
+	maxGapPenalty    = 10
+)
+
+// fuzzyMatch reports whether every rune of pattern appears in text in order,
+// ignoring case. It returns a score (higher is better) that rewards
+// consecutive runs and matches at word boundaries, and the rune positions in
+// text that matched. An empty pattern matches everything with score 0.
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+	positions := make([]int, 0, len(p))
+	score := 0
+	prev := -1
+
+	for ti, pi := 0, 0; pi < len(p); ti++ {
+		if ti >= len(t) {
+			return 0, nil, false
+		}
+		if unicode.ToLower(t[ti]) != p[pi] {
+			continue
+		}
+
+		score += scoreMatch
+		if prev >= 0 && ti == prev+1 {
+			score += scoreConsecutive
+		}
+		if isWordBoundary(t, ti) {
+			score += scoreBoundary
+		}
+		if prev >= 0 {
+			score -= min((ti-prev-1)*penaltyGap, maxGapPenalty)
+		}
+
+		positions = append(positions, ti)
+		prev = ti
+		pi++
+	}
+	return score, positions, true
+}
+
+// isWordBoundary reports whether t[i] starts a word: the first rune, a rune
+// after a separator, or an upper-case rune after a lower-case one.
+func isWordBoundary(t []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := t[i-1]
+	switch prev {
+	case '-', '_', '/', '.', ' ', ':':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(t[i])
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		text      string
+		wantOK    bool
+		wantMatch []int
+	}{
+		{name: "empty pattern matches", pattern: "", text: "api", wantOK: true},
+		{name: "exact prefix", pattern: "api", text: "api-gateway", wantOK: true, wantMatch: []int{0, 1, 2}},
+		{name: "subsequence", pattern: "agw", text: "api-gateway", wantOK: true, wantMatch: []int{0, 4, 8}},
+		{name: "case insensitive", pattern: "API", text: "my-api", wantOK: true, wantMatch: []int{3, 4, 5}},
+		{name: "out of order fails", pattern: "ipa", text: "api", wantOK: false},
+		{name: "missing rune fails", pattern: "apix", text: "api", wantOK: false},
+		{name: "unicode runes", pattern: "é", text: "café", wantOK: true, wantMatch: []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, matches, ok := fuzzyMatch(tt.pattern, tt.text)
+			if ok != tt.wantOK {
+				t.Fatalf("fuzzyMatch(%q, %q) ok = %v, want %v", tt.pattern, tt.text, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(matches, tt.wantMatch) {
+				t.Errorf("fuzzyMatch(%q, %q) matches = %v, want %v", tt.pattern, tt.text, matches, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchScoring(t *testing.T) {
+	better, _, _ := fuzzyMatch("api", "api")
+	worse, _, _ := fuzzyMatch("api", "a-pi-x")
+	if better <= worse {
+		t.Errorf("consecutive match score %d not above scattered match score %d", better, worse)
+	}
+
+	boundary, _, _ := fuzzyMatch("gw", "api-gateway")
+	inner, _, _ := fuzzyMatch("gw", "xxgxxwxx")
+	if boundary <= inner {
+		t.Errorf("word-boundary score %d not above inner score %d", boundary, inner)
+	}
+}
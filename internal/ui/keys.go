@@ -5,14 +5,25 @@ import "github.com/charmbracelet/bubbles/key"
 type keyMap struct {
 	Up      key.Binding
 	Down    key.Binding
+	PageUp  key.Binding
+	PageDn  key.Binding
+	Top     key.Binding
+	Bottom  key.Binding
 	Left    key.Binding
 	Right   key.Binding
 	Select  key.Binding
 	Kill    key.Binding
+	Apply   key.Binding
+	Restart key.Binding
 	Rename  key.Binding
 	Confirm key.Binding
 	Cancel  key.Binding
 	Quit    key.Binding
+
+	Filter      key.Binding
+	FilterUp    key.Binding
+	FilterDown  key.Binding
+	ClearFilter key.Binding
 }
 
 // keys defines the default key bindings for the picker.
@@ -25,6 +36,22 @@ var keys = keyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓/j", "down"),
 	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup", "page up"),
+	),
+	PageDn: key.NewBinding(
+		key.WithKeys("pgdown"),
+		key.WithHelp("pgdn", "page down"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("g", "home"),
+		key.WithHelp("g", "top"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("G", "end"),
+		key.WithHelp("G", "bottom"),
+	),
 	Left: key.NewBinding(
 		key.WithKeys("left", "h"),
 		key.WithHelp("←/h", "collapse/parent"),
@@ -41,6 +68,14 @@ var keys = keyMap{
 		key.WithKeys("d"),
 		key.WithHelp("d", "kill"),
 	),
+	Apply: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "apply layout"),
+	),
+	Restart: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "restart"),
+	),
 	Rename: key.NewBinding(
 		key.WithKeys("r"),
 		key.WithHelp("r", "rename"),
@@ -57,4 +92,20 @@ var keys = keyMap{
 		key.WithKeys("q", "esc"),
 		key.WithHelp("q", "quit"),
 	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	FilterUp: key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "ctrl+k"),
+		key.WithHelp("↑/ctrl+p", "up"),
+	),
+	FilterDown: key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "ctrl+j"),
+		key.WithHelp("↓/ctrl+n", "down"),
+	),
+	ClearFilter: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "clear filter"),
+	),
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/rmvaldesd/tplm/internal/config"
@@ -17,6 +18,7 @@ const (
 	modeNormal mode = iota
 	modeConfirmKill
 	modeRename
+	modeFilter
 )
 
 // Render format strings for picker items.
@@ -45,37 +47,66 @@ type pickerItem struct {
 	sessionName  string // parent session name (windows only)
 	windowIndex  int    // tmux window index (windows only)
 	windowActive bool   // active window indicator (windows only)
+	matches      []int  // rune positions in name matched by the filter
+	pathMatches  []int  // rune positions in path matched by the filter (projects only)
 }
 
 // PickerModel is the Bubbletea model for the two-section picker.
 type PickerModel struct {
 	cfg          *config.Config
+	client       *tmux.Client
 	projects     []pickerItem
 	sessions     []pickerItem
 	displayItems []pickerItem // flattened list the cursor navigates
 	expanded     map[string][]tmux.WindowInfo
-	cursor       int // index into displayItems
+	cursor       int    // index into displayItems
+	offset       int    // first body line shown when the list is taller than the picker
+	preview      string // captured pane or layout description of the selected item
+	previewKey   string // what preview shows, to recapture only when the selection changes
 	mode         mode
 	rename       RenameModel
+	filter       textinput.Model
+	query        string // active filter; empty shows everything
 	err          error
+	status       string // work running in the background, such as a session being built
 	quitting     bool
 	width        int
 	height       int
 }
 
+// stopDoneMsg reports that a session's on_stop steps have finished, so it
+// can be killed unless they failed.
+type stopDoneMsg struct {
+	name string
+	err  error
+}
+
 // switchMsg tells the program to switch to a session and quit.
 type switchMsg struct{ name string }
 
-// NewPicker creates a new picker model.
-func NewPicker(cfg *config.Config) PickerModel {
+// projectDoneMsg reports that the background work on a project's session,
+// started by openProject, applyProject or restartProject, has finished.
+type projectDoneMsg struct {
+	name     string
+	err      error
+	switchTo bool // switch to the session once it's built
+}
+
+// NewPicker creates a new picker model that talks to tmux through client.
+func NewPicker(cfg *config.Config, client *tmux.Client) PickerModel {
 	m := PickerModel{
 		cfg:      cfg,
+		client:   client,
 		expanded: make(map[string][]tmux.WindowInfo),
+		filter:   newFilterInput(),
 	}
 	m.refreshItems()
+	if warnings := cfg.Warnings(); len(warnings) > 0 {
+		m.err = warnings[0]
+	}
 
 	// Auto-expand the current tmux session.
-	if current, err := tmux.CurrentSession(); err == nil && current != "" {
+	if current, err := m.client.CurrentSession(); err == nil && current != "" {
 		for i, item := range m.displayItems {
 			if item.isSession && item.name == current {
 				m.cursor = i
@@ -97,7 +128,7 @@ func (m *PickerModel) refreshItems() {
 		})
 	}
 
-	sessions, _ := tmux.ListSessions()
+	sessions, _ := m.client.ListSessions()
 	m.sessions = make([]pickerItem, 0, len(sessions))
 	for _, s := range sessions {
 		_, isExpanded := m.expanded[s.Name]
@@ -124,6 +155,23 @@ func (m *PickerModel) refreshItems() {
 	m.rebuildDisplayItems()
 }
 
+// WithQuery returns the picker with its filter pre-filled and focused, so it
+// opens showing only items that match query.
+func (m PickerModel) WithQuery(query string) PickerModel {
+	m.filter.SetValue(query)
+	m.filter.Focus()
+	m.mode = modeFilter
+	m.setQuery(query)
+	return m
+}
+
+// setQuery applies a new filter query and moves the cursor to the best match.
+func (m *PickerModel) setQuery(query string) {
+	m.query = query
+	m.rebuildDisplayItems()
+	m.cursor = 0
+}
+
 func (m *PickerModel) rebuildDisplayItems() {
 	m.displayItems = make([]pickerItem, 0, len(m.projects)+len(m.sessions)+len(m.expanded))
 
@@ -147,6 +195,10 @@ func (m *PickerModel) rebuildDisplayItems() {
 			}
 		}
 	}
+
+	if m.query != "" {
+		m.displayItems = filterItems(m.displayItems, m.query)
+	}
 }
 
 func (m PickerModel) totalItems() int {
@@ -162,21 +214,59 @@ func (m PickerModel) selectedItem() *pickerItem {
 
 // Init implements tea.Model. It requests the initial window size.
 func (m PickerModel) Init() tea.Cmd {
-	return tea.WindowSize()
+	if m.mode == modeFilter {
+		return tea.Batch(tea.WindowSize(), previewTick(), textinput.Blink)
+	}
+	return tea.Batch(tea.WindowSize(), previewTick())
 }
 
-// Update implements tea.Model. It handles messages for all picker modes.
+// Update implements tea.Model. It handles messages for all picker modes and
+// then scrolls the list so the cursor stays visible and previews the selection.
 func (m PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if pm, ok := next.(PickerModel); ok && !pm.quitting {
+		pm.scrollToCursor()
+		pm.refreshPreview(false)
+		next = pm
+	}
+	return next, cmd
+}
+
+func (m PickerModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, nil
 
+	case previewTickMsg:
+		m.refreshPreview(true)
+		return m, previewTick()
+
+	case projectDoneMsg:
+		m.status = ""
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		if msg.switchTo && msg.err == nil {
+			return m, func() tea.Msg { return switchMsg{name: msg.name} }
+		}
+		m.refreshProject(msg.name)
+		return m, nil
+
+	case stopDoneMsg:
+		m.status = ""
+		if msg.err != nil {
+			// The session keeps running when its on_stop fails.
+			m.err = msg.err
+			return m, nil
+		}
+		return m.killSession(msg.name)
+
 	case switchMsg:
 		// Perform the switch and exit.
 		m.quitting = true
-		if err := tmux.SwitchClient(msg.name); err != nil {
+		if err := m.client.SwitchClient(msg.name); err != nil {
 			m.err = err
 		}
 		return m, tea.Quit
@@ -187,7 +277,7 @@ func (m PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			delete(m.expanded, msg.oldName)
 			m.expanded[msg.newName] = wins
 		}
-		if err := tmux.RenameSession(msg.oldName, msg.newName); err != nil {
+		if err := m.client.RenameSession(msg.oldName, msg.newName); err != nil {
 			m.err = err
 		}
 		m.mode = modeNormal
@@ -199,12 +289,24 @@ func (m PickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// While a session is being built only quitting is allowed, so the same
+	// work can't be started twice.
+	if msg, ok := msg.(tea.KeyMsg); ok && m.status != "" {
+		if key.Matches(msg, keys.Quit) {
+			m.quitting = true
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
 	// Delegate to sub-modes.
 	switch m.mode {
 	case modeConfirmKill:
 		return m.updateConfirmKill(msg)
 	case modeRename:
 		return m.updateRename(msg)
+	case modeFilter:
+		return m.updateFilter(msg)
 	default:
 		return m.updateNormal(msg)
 	}
@@ -214,10 +316,18 @@ func (m PickerModel) updateNormal(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
+		case m.query != "" && key.Matches(msg, keys.ClearFilter):
+			m.filter.SetValue("")
+			m.setQuery("")
+
 		case key.Matches(msg, keys.Quit):
 			m.quitting = true
 			return m, tea.Quit
 
+		case key.Matches(msg, keys.Filter):
+			m.mode = modeFilter
+			return m, m.filter.Focus()
+
 		case key.Matches(msg, keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
@@ -228,6 +338,18 @@ func (m PickerModel) updateNormal(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 
+		case key.Matches(msg, keys.PageUp):
+			m.moveCursor(-m.pageSize())
+
+		case key.Matches(msg, keys.PageDn):
+			m.moveCursor(m.pageSize())
+
+		case key.Matches(msg, keys.Top):
+			m.cursor = 0
+
+		case key.Matches(msg, keys.Bottom):
+			m.moveCursor(m.totalItems())
+
 		case key.Matches(msg, keys.Select):
 			item := m.selectedItem()
 			if item == nil {
@@ -253,19 +375,9 @@ func (m PickerModel) updateNormal(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			// It's a project — create session if needed, then switch.
-			proj := m.cfg.FindProject(item.name)
-			if proj == nil {
-				break
-			}
-			if tmux.SessionExists(proj.Name) {
-				return m, func() tea.Msg { return switchMsg{name: proj.Name} }
-			}
-			// Create session from layout.
-			if err := m.createSession(proj); err != nil {
-				m.err = err
-				break
+			if cmd := m.openProject(item.name); cmd != nil {
+				return m, cmd
 			}
-			return m, func() tea.Msg { return switchMsg{name: proj.Name} }
 
 		case key.Matches(msg, keys.Right):
 			item := m.selectedItem()
@@ -295,18 +407,9 @@ func (m PickerModel) updateNormal(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			// Project — open/switch (same as Enter).
-			proj := m.cfg.FindProject(item.name)
-			if proj == nil {
-				break
-			}
-			if tmux.SessionExists(proj.Name) {
-				return m, func() tea.Msg { return switchMsg{name: proj.Name} }
+			if cmd := m.openProject(item.name); cmd != nil {
+				return m, cmd
 			}
-			if err := m.createSession(proj); err != nil {
-				m.err = err
-				break
-			}
-			return m, func() tea.Msg { return switchMsg{name: proj.Name} }
 
 		case key.Matches(msg, keys.Left):
 			item := m.selectedItem()
@@ -330,6 +433,18 @@ func (m PickerModel) updateNormal(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.mode = modeConfirmKill
 			}
 
+		case key.Matches(msg, keys.Apply):
+			item := m.selectedItem()
+			if item != nil && !item.isWindow {
+				return m, m.applyProject(item.name)
+			}
+
+		case key.Matches(msg, keys.Restart):
+			item := m.selectedItem()
+			if item != nil && !item.isWindow {
+				return m, m.restartProject(item.name)
+			}
+
 		case key.Matches(msg, keys.Rename):
 			item := m.selectedItem()
 			if item != nil && item.isSession {
@@ -349,36 +464,15 @@ func (m PickerModel) updateConfirmKill(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Confirm):
 			item := m.selectedItem()
 			if item != nil && item.isSession {
-				currentSession, _ := tmux.CurrentSession()
-				isCurrentSession := item.name == currentSession
-
-				if isCurrentSession {
-					neighbor, hasNeighbor := tmux.NeighborSession(item.name)
-					if hasNeighbor {
-						_ = tmux.SwitchClient(neighbor)
-					}
-					if err := tmux.KillSession(item.name); err != nil {
-						m.err = err
-					}
-					if !hasNeighbor {
-						// Last session — quit so picker exits before tmux shuts down.
-						m.quitting = true
-						m.mode = modeNormal
-						return m, tea.Quit
-					}
-				} else {
-					if err := tmux.KillSession(item.name); err != nil {
-						m.err = err
-					}
-				}
-
-				m.refreshItems()
-				if m.cursor >= m.totalItems() && m.cursor > 0 {
-					m.cursor--
-				}
+				// on_stop can wait on the session's processes; run it in the
+				// background and kill the session once it's done.
+				name := item.name
+				m.mode = modeNormal
+				m.status = fmt.Sprintf(MsgStopping, name)
+				return m, func() tea.Msg { return stopDoneMsg{name: name, err: m.runOnStop(name)} }
 			} else if item != nil && item.isWindow {
 				target := fmt.Sprintf(tmux.FmtSessionWindow, item.sessionName, item.windowIndex)
-				if err := tmux.KillWindow(target); err != nil {
+				if err := m.client.KillWindow(target); err != nil {
 					m.err = err
 				}
 				m.refreshItems()
@@ -394,6 +488,81 @@ func (m PickerModel) updateConfirmKill(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// killSession kills the named session, first moving the client to a
+// neighbor if it's the current one. Killing the last session quits the picker
+// before tmux shuts down.
+func (m PickerModel) killSession(name string) (tea.Model, tea.Cmd) {
+	currentSession, _ := m.client.CurrentSession()
+	if name == currentSession {
+		neighbor, hasNeighbor := m.client.NeighborSession(name)
+		if hasNeighbor {
+			_ = m.client.SwitchClient(neighbor)
+		}
+		if err := m.client.KillSession(name); err != nil {
+			m.err = err
+		}
+		if !hasNeighbor {
+			m.quitting = true
+			return m, tea.Quit
+		}
+	} else if err := m.client.KillSession(name); err != nil {
+		m.err = err
+	}
+
+	m.refreshItems()
+	if m.cursor >= m.totalItems() && m.cursor > 0 {
+		m.cursor--
+	}
+	return m, nil
+}
+
+// updateFilter handles typing into the filter. Enter leaves filter mode
+// (keeping the filter applied) and acts on the selection as in normal mode.
+func (m PickerModel) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, keys.ClearFilter):
+			m.filter.SetValue("")
+			m.filter.Blur()
+			m.setQuery("")
+			m.mode = modeNormal
+			return m, nil
+
+		case key.Matches(msg, keys.Select):
+			m.filter.Blur()
+			m.mode = modeNormal
+			return m.updateNormal(msg)
+
+		case key.Matches(msg, keys.FilterUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.FilterDown):
+			if m.cursor < m.totalItems()-1 {
+				m.cursor++
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.PageUp):
+			m.moveCursor(-m.pageSize())
+			return m, nil
+
+		case key.Matches(msg, keys.PageDn):
+			m.moveCursor(m.pageSize())
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	if query := m.filter.Value(); query != m.query {
+		m.setQuery(query)
+	}
+	return m, cmd
+}
+
 func (m PickerModel) updateRename(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	m.rename, cmd = m.rename.Update(msg)
@@ -402,7 +571,7 @@ func (m PickerModel) updateRename(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // expandSession expands a session to show its windows.
 func (m *PickerModel) expandSession(item *pickerItem) error {
-	wins, err := tmux.ListWindows(item.name)
+	wins, err := m.client.ListWindows(item.name)
 	if err != nil {
 		return err
 	}
@@ -414,12 +583,12 @@ func (m *PickerModel) expandSession(item *pickerItem) error {
 		}
 	}
 	m.rebuildDisplayItems()
+	m.selectSession(item.name)
 	return nil
 }
 
 // collapseSession collapses a session, hiding its windows.
 func (m *PickerModel) collapseSession(item *pickerItem) {
-	sessionIdx := m.cursor
 	delete(m.expanded, item.name)
 	for i := range m.sessions {
 		if m.sessions[i].name == item.name {
@@ -428,14 +597,23 @@ func (m *PickerModel) collapseSession(item *pickerItem) {
 		}
 	}
 	m.rebuildDisplayItems()
-	if m.cursor > sessionIdx {
-		m.cursor = sessionIdx
-	}
+	m.selectSession(item.name)
 	if m.cursor >= m.totalItems() && m.cursor > 0 {
 		m.cursor = m.totalItems() - 1
 	}
 }
 
+// selectSession moves the cursor to the named session. With a filter active,
+// expanding or collapsing a session changes its score, so it can move.
+func (m *PickerModel) selectSession(name string) {
+	for i, item := range m.displayItems {
+		if item.isSession && item.name == name {
+			m.cursor = i
+			return
+		}
+	}
+}
+
 // findParentSessionIndex scans backwards from the current cursor to find the parent session.
 func (m *PickerModel) findParentSessionIndex() int {
 	for i := m.cursor - 1; i >= 0; i-- {
@@ -446,18 +624,165 @@ func (m *PickerModel) findParentSessionIndex() int {
 	return m.cursor
 }
 
+// projectClient returns a client for the tmux server the project's session
+// lives on, which differs from the picker's server only when the project sets
+// its own tmux_socket.
+func (m *PickerModel) projectClient(proj *config.Project) *tmux.Client {
+	if socket := m.cfg.Socket(proj); socket != m.cfg.TmuxSocket {
+		return tmux.NewClient(tmux.SocketRunner(socket))
+	}
+	return m.client
+}
+
+// openProject returns the command that switches to the project's session.
+// A session that doesn't exist yet is built first, in the background, since
+// its wait_for conditions can take a while; m.status shows it meanwhile. It
+// returns nil, setting m.err on failure, when there is nothing to switch to.
+func (m *PickerModel) openProject(name string) tea.Cmd {
+	proj := m.cfg.FindProject(name)
+	if proj == nil {
+		return nil
+	}
+	client := m.projectClient(proj)
+	// A client can only switch between sessions of its own server.
+	other := client != m.client
+	if client.SessionExists(proj.Name) {
+		if other {
+			m.err = fmt.Errorf(ErrFmtOtherServer, proj.Name, m.cfg.Socket(proj))
+			return nil
+		}
+		return func() tea.Msg { return switchMsg{name: proj.Name} }
+	}
+	return m.inBackground(MsgStarting, proj.Name, func() error {
+		if err := m.createSession(proj); err != nil {
+			return err
+		}
+		if other {
+			return fmt.Errorf(ErrFmtOtherServer, proj.Name, m.cfg.Socket(proj))
+		}
+		return nil
+	}, !other)
+}
+
+// applyProject returns the command that brings the project's running session
+// in line with its layout, adding missing windows and panes and leaving
+// existing ones alone. A project without a session, or a session that isn't
+// a project, is left as is.
+func (m *PickerModel) applyProject(name string) tea.Cmd {
+	proj := m.cfg.FindProject(name)
+	if proj == nil {
+		return nil
+	}
+	client := m.projectClient(proj)
+	if !client.SessionExists(proj.Name) {
+		return nil
+	}
+	// The picker can't prompt; the error points the user at tplm trust.
+	if err := config.CheckTrusted(proj); err != nil {
+		m.err = err
+		return nil
+	}
+
+	return m.inBackground(MsgApplying, proj.Name, func() error {
+		env, err := m.cfg.SessionEnv(proj)
+		if err == nil {
+			err = client.SetEnvironment(proj.Name, env)
+		}
+		if err != nil {
+			return err
+		}
+		r, err := client.ReconcileLayout(proj.Name, m.cfg.GetLayout(proj), proj.Path, false)
+		if err == nil && len(r.Added) > 0 && len(proj.OnStart) > 0 {
+			err = client.RunOnStart(r.Added, proj.OnStart, proj.Path)
+		}
+		return err
+	}, false)
+}
+
+// restartProject returns the command that kills the project's session and
+// creates it again from its layout, keeping the focused window and pane. A
+// project without a session, or a session that isn't a project, is left as
+// is.
+func (m *PickerModel) restartProject(name string) tea.Cmd {
+	proj := m.cfg.FindProject(name)
+	if proj == nil {
+		return nil
+	}
+	client := m.projectClient(proj)
+	if !client.SessionExists(proj.Name) {
+		return nil
+	}
+	if err := config.CheckTrusted(proj); err != nil {
+		m.err = err
+		return nil
+	}
+
+	return m.inBackground(MsgRestarting, proj.Name, func() error {
+		return client.RestartSession(proj.Name, func() error { return m.createSession(proj) })
+	}, false)
+}
+
+// inBackground sets the status to format with name and returns the command
+// that runs work and reports its result with a projectDoneMsg.
+func (m *PickerModel) inBackground(format, name string, work func() error, switchTo bool) tea.Cmd {
+	m.status = fmt.Sprintf(format, name)
+	return func() tea.Msg {
+		return projectDoneMsg{name: name, err: work(), switchTo: switchTo}
+	}
+}
+
+// refreshProject lists the items again after the project's session changed,
+// keeping its windows up to date if it's expanded.
+func (m *PickerModel) refreshProject(name string) {
+	if _, ok := m.expanded[name]; ok {
+		if proj := m.cfg.FindProject(name); proj == nil || m.projectClient(proj) == m.client {
+			if wins, err := m.client.ListWindows(name); err == nil {
+				m.expanded[name] = wins
+			}
+		}
+	}
+	m.refreshItems()
+}
+
+// runOnStop runs the on_stop steps of the project the session belongs to. A
+// session that isn't a project, or whose project lives on another server,
+// has none.
+func (m *PickerModel) runOnStop(session string) error {
+	proj := m.cfg.FindProject(session)
+	if proj == nil || len(proj.OnStop) == 0 || m.projectClient(proj) != m.client {
+		return nil
+	}
+	if err := config.CheckTrusted(proj); err != nil {
+		return err
+	}
+	return m.client.RunOnStop(proj.Name, proj.Path, proj.OnStop)
+}
+
 func (m *PickerModel) createSession(proj *config.Project) error {
-	if err := tmux.NewSession(proj.Name, proj.Path); err != nil {
+	if err := config.CheckTrusted(proj); err != nil {
+		return err
+	}
+	if err := tmux.RunBeforeStart(proj.Path, proj.BeforeStart); err != nil {
+		return err
+	}
+
+	env, err := m.cfg.SessionEnv(proj)
+	if err != nil {
+		return err
+	}
+	client := m.projectClient(proj)
+	if err := client.NewSession(proj.Name, proj.Path, env.Pairs()...); err != nil {
 		return err
 	}
 
 	layout := m.cfg.GetLayout(proj)
-	if err := tmux.ApplyLayout(proj.Name, layout, proj.Path); err != nil {
+	windows, err := client.ApplyLayout(proj.Name, layout, proj.Path)
+	if err != nil {
 		return err
 	}
 
 	if len(proj.OnStart) > 0 {
-		if err := tmux.RunOnStart(proj.Name, layout, proj.OnStart); err != nil {
+		if err := client.RunOnStart(windows, proj.OnStart, proj.Path); err != nil {
 			return err
 		}
 	}
@@ -478,15 +803,43 @@ func (m PickerModel) View() string {
 		w = DefaultPickerWidth
 	}
 
-	// Title bar.
+	b.WriteString(m.titleBar(w))
+	for _, line := range m.withPreview(m.visibleBody(m.listWidth(w)), w) {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString(m.footer())
+	return b.String()
+}
+
+// titleBar renders the title and hint line followed by a separator.
+func (m PickerModel) titleBar(w int) string {
 	title := titleStyle.Render(TitleText)
 	hint := pathStyle.Render(HintText)
 	gap := w - lipgloss.Width(title) - lipgloss.Width(hint)
 	if gap < 1 {
 		gap = 1
 	}
-	b.WriteString(title + strings.Repeat(" ", gap) + hint + "\n")
-	b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
+	return title + strings.Repeat(" ", gap) + hint + "\n" +
+		separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n"
+}
+
+// bodyLines renders every line of the project and session sections, with
+// section headers, tagged so the viewport can clip and pin them.
+func (m PickerModel) bodyLines(w int) []bodyLine {
+	lines := make([]bodyLine, 0, len(m.displayItems)+sectionHeaderLines*2)
+	separator := separatorStyle.Render(strings.Repeat(SymbolSeparator, w))
+	header := func(section int, text string) {
+		lines = append(lines, bodyLine{text: text, section: section, header: true, item: noItem})
+	}
+	message := func(section int, text string) {
+		lines = append(lines, bodyLine{text: normalStyle.Render(text), section: section, item: noItem})
+	}
+
+	// Empty sections say why they are empty.
+	noProjects, noSessions := MsgNoProjects, MsgNoSessions
+	if m.query != "" {
+		noProjects, noSessions = MsgNoMatches, MsgNoMatches
+	}
 
 	// Render using displayItems with section headers.
 	inSessions := false
@@ -495,43 +848,54 @@ func (m PickerModel) View() string {
 	for i, item := range m.displayItems {
 		// Insert Projects header before first project.
 		if !projectsRendered && !item.isSession && !item.isWindow {
-			b.WriteString(headerStyle.Render(HeaderProjects) + "\n")
-			b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
+			header(sectionProjects, headerStyle.Render(HeaderProjects))
+			header(sectionProjects, separator)
 			projectsRendered = true
 		}
 
 		// Insert Sessions header at the boundary.
 		if !inSessions && (item.isSession || item.isWindow) {
 			if !projectsRendered {
-				b.WriteString(headerStyle.Render(HeaderProjects) + "\n")
-				b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
-				b.WriteString(normalStyle.Render(MsgNoProjects) + "\n")
+				header(sectionProjects, headerStyle.Render(HeaderProjects))
+				header(sectionProjects, separator)
+				message(sectionProjects, noProjects)
 				projectsRendered = true
 			}
-			b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
-			b.WriteString(headerStyle.Render(HeaderSessions) + "\n")
-			b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
+			header(sectionSessions, separator)
+			header(sectionSessions, headerStyle.Render(HeaderSessions))
+			header(sectionSessions, separator)
 			inSessions = true
 		}
 
-		b.WriteString(m.renderItem(i, item, w))
+		section := sectionProjects
+		if inSessions {
+			section = sectionSessions
+		}
+		text := strings.TrimSuffix(m.renderItem(i, item, w), "\n")
+		lines = append(lines, bodyLine{text: text, section: section, item: i})
 	}
 
 	// Handle empty states.
 	if !projectsRendered {
-		b.WriteString(headerStyle.Render(HeaderProjects) + "\n")
-		b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
-		b.WriteString(normalStyle.Render(MsgNoProjects) + "\n")
+		header(sectionProjects, headerStyle.Render(HeaderProjects))
+		header(sectionProjects, separator)
+		message(sectionProjects, noProjects)
 	}
 
 	if !inSessions {
-		b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
-		b.WriteString(headerStyle.Render(HeaderSessions) + "\n")
-		b.WriteString(separatorStyle.Render(strings.Repeat(SymbolSeparator, w)) + "\n")
-		b.WriteString(normalStyle.Render(MsgNoSessions) + "\n")
+		header(sectionSessions, separator)
+		header(sectionSessions, headerStyle.Render(HeaderSessions))
+		header(sectionSessions, separator)
+		message(sectionSessions, noSessions)
 	}
 
-	// Mode-specific footer.
+	return lines
+}
+
+// footer renders the mode-specific footer and any error.
+func (m PickerModel) footer() string {
+	var b strings.Builder
+
 	switch m.mode {
 	case modeConfirmKill:
 		item := m.selectedItem()
@@ -546,11 +910,22 @@ func (m PickerModel) View() string {
 	case modeRename:
 		b.WriteString("\n")
 		b.WriteString(m.rename.View() + "\n")
+	case modeFilter:
+		b.WriteString("\n")
+		b.WriteString(m.filter.View() + "\n")
 	default:
+		if m.query != "" {
+			b.WriteString("\n")
+			b.WriteString(m.filter.View() + "\n")
+		}
 		b.WriteString("\n")
 		b.WriteString(helpStyle.Render(MsgHelpBar) + "\n")
 	}
 
+	if m.status != "" {
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(m.status) + "\n")
+	}
 	if m.err != nil {
 		b.WriteString("\n")
 		b.WriteString(confirmStyle.Render(fmt.Sprintf(MsgError, m.err)) + "\n")
@@ -572,7 +947,7 @@ func (m PickerModel) renderItem(idx int, item pickerItem, width int) string {
 		if item.windowActive {
 			indicator = windowActiveIndicator.Render() + " "
 		}
-		name := style.Render(item.name)
+		name := highlight(item.name, item.matches, style)
 		return fmt.Sprintf(fmtWindowItem, cursor, indicator, name)
 	}
 
@@ -582,7 +957,7 @@ func (m PickerModel) renderItem(idx int, item pickerItem, width int) string {
 			chevron = SymbolChevronDown
 		}
 		indicator := activeIndicator.Render()
-		name := style.Render(item.name)
+		name := highlight(item.name, item.matches, style)
 		info := ""
 		if !item.expanded {
 			info = "  " + pathStyle.Render(fmt.Sprintf(fmtWindowInfo, item.windows))
@@ -590,7 +965,7 @@ func (m PickerModel) renderItem(idx int, item pickerItem, width int) string {
 		return fmt.Sprintf(fmtSessionItem, cursor, indicator, chevron, name, info)
 	}
 
-	name := style.Render(item.name)
-	path := pathStyle.Render(item.path)
+	name := highlight(item.name, item.matches, style)
+	path := highlight(item.path, item.pathMatches, pathStyle)
 	return fmt.Sprintf(fmtProjectItem, cursor, name, path)
 }
@@ -0,0 +1,516 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+	"github.com/rmvaldesd/tplm/internal/tmux/tmuxtest"
+)
+
+// cmdTimeout bounds how long press waits for a command's message.
+const cmdTimeout = 50 * time.Millisecond
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Projects: []config.Project{
+			{Name: "api", Path: "/src/api", Layout: "dev"},
+			{Name: "web", Path: "/src/web"},
+		},
+		Layouts: map[string]config.Layout{
+			"dev": {Windows: []config.Window{{Name: "editor"}, {Name: "server"}}},
+		},
+	}
+}
+
+func keyPress(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "pgup":
+		return tea.KeyMsg{Type: tea.KeyPgUp}
+	case "pgdown":
+		return tea.KeyMsg{Type: tea.KeyPgDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+// press feeds keys to the model, running the resulting commands so messages
+// such as projectDoneMsg and the switchMsg after it are delivered back to
+// Update. Commands that don't finish promptly, such as cursor blinks, are
+// dropped.
+func press(t *testing.T, m PickerModel, keys ...string) PickerModel {
+	t.Helper()
+	for _, k := range keys {
+		next, cmd := m.Update(keyPress(k))
+		m = next.(PickerModel)
+		for msg := runCmd(cmd); msg != nil; msg = runCmd(cmd) {
+			next, cmd = m.Update(msg)
+			m = next.(PickerModel)
+		}
+	}
+	return m
+}
+
+// runCmd runs cmd and returns its message, or nil for quit, timers and
+// commands without a result.
+func runCmd(cmd tea.Cmd) tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		if _, quit := msg.(tea.QuitMsg); quit {
+			return nil
+		}
+		return msg
+	case <-time.After(cmdTimeout):
+		return nil
+	}
+}
+
+func TestNewPickerExpandsCurrentSession(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("other", "/tmp")
+	fake.AddSession("api", "/src/api", "editor", "server")
+	fake.Client = "api"
+
+	m := NewPicker(testConfig(), tmux.NewClient(fake))
+
+	item := m.selectedItem()
+	if item == nil || !item.isSession || item.name != "api" {
+		t.Fatalf("cursor on %+v, want session api", item)
+	}
+	if !item.expanded {
+		t.Error("current session not expanded")
+	}
+	// 2 projects + 2 sessions + 2 windows of the expanded session.
+	if got := m.totalItems(); got != 6 {
+		t.Errorf("totalItems() = %d, want 6", got)
+	}
+}
+
+func TestPickerSelectProjectCreatesSession(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("scratch", "/tmp")
+	fake.Client = "scratch"
+
+	m := NewPicker(testConfig(), tmux.NewClient(fake))
+	m.cursor = 0 // project "api"
+	m = press(t, m, "enter")
+
+	if m.err != nil {
+		t.Fatalf("picker error = %v", m.err)
+	}
+	s := fake.Session("api")
+	if s == nil {
+		t.Fatal("session api was not created")
+	}
+	if len(s.Windows) != 2 || s.Windows[0].Name != "editor" || s.Windows[1].Name != "server" {
+		t.Errorf("session windows = %+v, want editor, server", s.Windows)
+	}
+	if fake.Client != "api" {
+		t.Errorf("client attached to %q, want api", fake.Client)
+	}
+	if !m.quitting {
+		t.Error("picker did not quit after switching")
+	}
+}
+
+func TestPickerBuildsSessionInBackground(t *testing.T) {
+	fake := tmuxtest.New()
+	m := NewPicker(testConfig(), tmux.NewClient(fake))
+	m.cursor = 0 // project "api"
+
+	next, cmd := m.Update(keyPress("enter"))
+	m = next.(PickerModel)
+	if fake.Session("api") != nil {
+		t.Fatal("session built inside Update")
+	}
+	if !strings.Contains(m.footer(), "Starting api") {
+		t.Errorf("footer = %q, want a status while the session is built", m.footer())
+	}
+	if next, _ := m.Update(keyPress("enter")); next.(PickerModel).status != m.status {
+		t.Error("keys other than quit were handled while busy")
+	}
+
+	msg, ok := cmd().(projectDoneMsg)
+	if !ok || msg.err != nil || fake.Session("api") == nil {
+		t.Fatalf("command message = %+v, want api built", msg)
+	}
+	next, cmd = m.Update(msg)
+	m = next.(PickerModel)
+	if m.status != "" {
+		t.Errorf("status = %q after the build finished", m.status)
+	}
+	if sw, ok := runCmd(cmd).(switchMsg); !ok || sw.name != "api" {
+		t.Errorf("after the build got %+v, want a switch to api", sw)
+	}
+}
+
+func TestPickerKillCurrentSessionSwitchesToNeighbor(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api")
+	fake.AddSession("web", "/src/web")
+	fake.Client = "api"
+
+	m := NewPicker(testConfig(), tmux.NewClient(fake))
+	m = press(t, m, "d", "y")
+
+	if fake.Session("api") != nil {
+		t.Error("session api still exists")
+	}
+	if fake.Client != "web" {
+		t.Errorf("client attached to %q, want web", fake.Client)
+	}
+	if m.mode != modeNormal {
+		t.Errorf("mode = %v, want normal", m.mode)
+	}
+}
+
+func TestPickerRenameSession(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api")
+	fake.Client = "api"
+
+	m := NewPicker(testConfig(), tmux.NewClient(fake))
+	m = press(t, m, "r", "2", "enter")
+
+	if fake.Session("api2") == nil {
+		t.Errorf("sessions = %v, want api renamed to api2", fake.Commands())
+	}
+	if _, ok := m.expanded["api2"]; !ok {
+		t.Error("expanded state not carried over to renamed session")
+	}
+}
+
+func itemNames(items []pickerItem) []string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.name
+	}
+	return names
+}
+
+func TestPickerFilter(t *testing.T) {
+	cfg := &config.Config{Projects: []config.Project{
+		{Name: "api-gateway", Path: "/src/gateway"},
+		{Name: "frontend", Path: "/src/web"},
+		{Name: "infra", Path: "/src/ops/infra"},
+	}}
+	fake := tmuxtest.New()
+	fake.AddSession("api-gateway", "/src/gateway", "editor", "server")
+	fake.AddSession("notes", "/tmp", "web-docs")
+	fake.Client = "api-gateway"
+
+	m := NewPicker(cfg, tmux.NewClient(fake))
+	m.expandSession(&pickerItem{name: "notes"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "empty query shows everything", query: "", want: []string{
+			"api-gateway", "frontend", "infra", "api-gateway", "editor", "server", "notes", "web-docs",
+		}},
+		{name: "matches project name and session with all windows", query: "gate", want: []string{
+			"api-gateway", "api-gateway", "editor", "server",
+		}},
+		{name: "matches project path", query: "ops", want: []string{"infra"}},
+		{name: "window match keeps parent session", query: "docs", want: []string{"notes", "web-docs"}},
+		{name: "no matches", query: "zzz", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := press(t, m, "/")
+			for _, r := range tt.query {
+				m = press(t, m, string(r))
+			}
+			if got := itemNames(m.displayItems); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("displayItems = %v, want %v", got, tt.want)
+			}
+			if tt.query != "" && m.cursor != 0 {
+				t.Errorf("cursor = %d, want 0", m.cursor)
+			}
+		})
+	}
+}
+
+func TestPickerFilterToggleKeepsCursorOnSession(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("dashboard", "/src/dashboard", "db")
+	fake.AddSession("dev-box", "/tmp")
+
+	m := NewPicker(&config.Config{}, tmux.NewClient(fake)).WithQuery("db")
+	if got := itemNames(m.displayItems); strings.Join(got, ",") != "dev-box,dashboard" {
+		t.Fatalf("displayItems = %v, want dev-box ranked above dashboard", got)
+	}
+	m.cursor = 1
+
+	// Expanding lists the matching window db, which ranks dashboard first.
+	m = press(t, m, "enter")
+	if got := itemNames(m.displayItems); strings.Join(got, ",") != "dashboard,db,dev-box" {
+		t.Fatalf("displayItems after expand = %v", got)
+	}
+	if item := m.selectedItem(); item == nil || !item.isSession || item.name != "dashboard" {
+		t.Errorf("cursor on %+v after expand, want session dashboard", item)
+	}
+
+	m = press(t, m, "enter")
+	if item := m.selectedItem(); item == nil || !item.isSession || item.name != "dashboard" {
+		t.Errorf("cursor on %+v after collapse, want session dashboard", item)
+	}
+}
+
+func TestPickerFilterEnterAndClear(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("scratch", "/tmp")
+	fake.Client = "scratch"
+
+	m := NewPicker(testConfig(), tmux.NewClient(fake)).WithQuery("we")
+	if m.mode != modeFilter || itemNames(m.displayItems)[0] != "web" {
+		t.Fatalf("WithQuery: mode = %v, items = %v", m.mode, itemNames(m.displayItems))
+	}
+
+	// Esc clears the filter and leaves filter mode.
+	cleared := press(t, m, "esc")
+	// 2 projects + the current session, auto-expanded with its window.
+	if cleared.mode != modeNormal || cleared.query != "" || cleared.totalItems() != 4 {
+		t.Errorf("after esc: mode = %v, query = %q, items = %v", cleared.mode, cleared.query, itemNames(cleared.displayItems))
+	}
+
+	// Enter opens the selected match as in normal mode.
+	m = press(t, m, "enter")
+	if fake.Session("web") == nil || fake.Client != "web" {
+		t.Errorf("enter did not open project web; client on %q", fake.Client)
+	}
+}
+
+func TestPickerScrollKeepsCursorVisible(t *testing.T) {
+	cfg := &config.Config{}
+	for i := 0; i < 30; i++ {
+		name := fmt.Sprintf("proj%02d", i)
+		cfg.Projects = append(cfg.Projects, config.Project{Name: name, Path: "/src/" + name})
+	}
+	fake := tmuxtest.New()
+	fake.AddSession("scratch", "/tmp")
+	fake.Client = "scratch"
+
+	m := NewPicker(cfg, tmux.NewClient(fake))
+	// Wide enough for the preview to sit beside the list rather than below it.
+	next, _ := m.Update(tea.WindowSizeMsg{Width: previewSideMinWidth, Height: 20})
+	m = next.(PickerModel)
+
+	tests := []struct {
+		name   string
+		keys   []string
+		cursor int
+		above  bool // "↑ N more" shown
+		below  bool // "↓ N more" shown
+	}{
+		{name: "top", keys: []string{"g"}, cursor: 0, below: true},
+		{name: "page down", keys: []string{"g", "pgdown"}, cursor: m.pageSize(), below: true},
+		{name: "page up clamps", keys: []string{"g", "j", "pgup"}, cursor: 0, below: true},
+		{name: "bottom", keys: []string{"G"}, cursor: m.totalItems() - 1, above: true},
+		{name: "two pages down", keys: []string{"g", "pgdown", "pgdown"}, cursor: 2 * m.pageSize(), above: true, below: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := press(t, m, tt.keys...)
+			if m.cursor != tt.cursor {
+				t.Fatalf("cursor = %d, want %d", m.cursor, tt.cursor)
+			}
+			view := m.View()
+			if lines := strings.Count(view, "\n"); lines >= m.height {
+				t.Errorf("view has %d lines, want fewer than %d", lines, m.height)
+			}
+			if selected := m.displayItems[m.cursor].name; !strings.Contains(view, selected) {
+				t.Errorf("selected item %q not in view:\n%s", selected, view)
+			}
+			if got := strings.Contains(view, "↑"); got != tt.above {
+				t.Errorf("more-above indicator shown = %v, want %v:\n%s", got, tt.above, view)
+			}
+			if got := strings.Contains(view, "↓"); got != tt.below {
+				t.Errorf("more-below indicator shown = %v, want %v:\n%s", got, tt.below, view)
+			}
+			if !strings.Contains(view, HeaderProjects) {
+				t.Errorf("projects header not pinned:\n%s", view)
+			}
+		})
+	}
+}
+
+func TestPickerPreview(t *testing.T) {
+	fake := tmuxtest.New()
+	s := fake.AddSession("scratch", "/tmp", "shell", "logs")
+	s.Windows[0].Panes[0].Content = "$ make test\nok"
+	s.Windows[1].Panes[0].Content = "GET /health 200"
+	fake.Client = "scratch"
+
+	cfg := testConfig()
+	cfg.Projects[0].OnStart = []config.OnStart{{Window: "server", Command: "npm start"}}
+	m := NewPicker(cfg, tmux.NewClient(fake))
+
+	tests := []struct {
+		name   string
+		width  int
+		cursor int
+		want   []string
+	}{
+		{name: "project shows its layout", width: previewSideMinWidth, cursor: 0, want: []string{
+			"/src/api", MsgPreviewNotRunning, "editor", "server", "on start: npm start",
+		}},
+		{name: "session shows its active pane", width: previewSideMinWidth, cursor: 2, want: []string{"$ make test", "ok"}},
+		{name: "window shows its pane", width: previewSideMinWidth, cursor: 4, want: []string{"GET /health 200"}},
+		{name: "narrow picker shows preview below", width: DefaultPickerWidth, cursor: 2, want: []string{"$ make test"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := m
+			m.cursor = tt.cursor
+			next, _ := m.Update(tea.WindowSizeMsg{Width: tt.width, Height: 30})
+			view := next.(PickerModel).View()
+			for _, want := range tt.want {
+				if !strings.Contains(view, want) {
+					t.Errorf("view lacks %q:\n%s", want, view)
+				}
+			}
+		})
+	}
+}
+
+func TestPickerApplyAddsMissingWindows(t *testing.T) {
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api", "editor")
+	fake.Client = "api"
+
+	m := NewPicker(testConfig(), tmux.NewClient(fake))
+	m = press(t, m, "a")
+
+	if m.err != nil {
+		t.Fatalf("picker error = %v", m.err)
+	}
+	if fake.Session("api").Window("server") == nil {
+		t.Error("missing window server was not added")
+	}
+	// 2 projects + the expanded session with both windows.
+	if got := itemNames(m.displayItems); len(got) != 5 || got[4] != "server" {
+		t.Errorf("displayItems = %v, want the new window listed", got)
+	}
+}
+
+func TestPickerRestartRebuildsSession(t *testing.T) {
+	t.Setenv(tmux.EnvTmux, "/tmp/tmux-1000/default,1,0")
+	fake := tmuxtest.New()
+	fake.AddSession("api", "/src/api", "editor", "scratch")
+	fake.AddSession("web", "/src/web")
+	fake.Client = "api"
+
+	m := NewPicker(testConfig(), tmux.NewClient(fake))
+	m = press(t, m, "R")
+
+	if m.err != nil {
+		t.Fatalf("picker error = %v", m.err)
+	}
+	s := fake.Session("api")
+	if s == nil || s.Window("server") == nil || s.Window("scratch") != nil {
+		t.Errorf("session api not rebuilt from its layout: %+v", s)
+	}
+	if fake.Client != "api" {
+		t.Errorf("client on %q, want back on api", fake.Client)
+	}
+}
+
+func TestPickerKillRunsOnStop(t *testing.T) {
+	cfg := testConfig()
+	cfg.Projects[0].OnStop = []config.OnStop{{Window: "server", Keys: "C-c"}}
+	fake := tmuxtest.New()
+	s := fake.AddSession("api", "/src/api", "editor", "server")
+	fake.AddSession("web", "/src/web")
+	fake.Client = "web"
+
+	m := NewPicker(cfg, tmux.NewClient(fake))
+	for i, item := range m.displayItems {
+		if item.isSession && item.name == "api" {
+			m.cursor = i
+		}
+	}
+	m = press(t, m, "d")
+
+	// on_stop runs in the background, not inside Update.
+	next, cmd := m.Update(keyPress("y"))
+	m = next.(PickerModel)
+	if keys := s.Window("server").Panes[0].Keys; len(keys) != 0 {
+		t.Errorf("on_stop sent %q inside Update", keys)
+	}
+	if !strings.Contains(m.footer(), "Stopping api") {
+		t.Errorf("footer = %q, want a status while on_stop runs", m.footer())
+	}
+	next, _ = m.Update(runCmd(cmd))
+	m = next.(PickerModel)
+
+	if m.err != nil || m.status != "" {
+		t.Fatalf("picker error = %v, status = %q", m.err, m.status)
+	}
+	if keys := s.Window("server").Panes[0].Keys; len(keys) != 1 || keys[0] != "C-c" {
+		t.Errorf("server keys = %q, want C-c before the kill", keys)
+	}
+	if fake.Session("api") != nil {
+		t.Error("session api still running")
+	}
+}
+
+func TestPickerBeforeStartFailureShowsInFooter(t *testing.T) {
+	cfg := testConfig()
+	cfg.Projects[0].Path = t.TempDir()
+	cfg.Projects[0].BeforeStart = []string{"echo vpn down >&2; exit 1"}
+	fake := tmuxtest.New()
+
+	m := NewPicker(cfg, tmux.NewClient(fake))
+	m = press(t, m, "enter")
+
+	if fake.Session("api") != nil {
+		t.Error("session created despite the failing hook")
+	}
+	if m.err == nil || !strings.Contains(m.footer(), "vpn down") {
+		t.Errorf("footer = %q, want the hook's output", m.footer())
+	}
+}
+
+func TestPickerInitWithQueryRefreshesPreview(t *testing.T) {
+	fake := tmuxtest.New()
+	m := NewPicker(testConfig(), tmux.NewClient(fake)).WithQuery("we")
+
+	batch, ok := m.Init()().(tea.BatchMsg)
+	if !ok {
+		t.Fatal("Init() didn't return a batch")
+	}
+	msgs := make(chan tea.Msg, len(batch))
+	for _, cmd := range batch {
+		go func() { msgs <- cmd() }()
+	}
+	timeout := time.After(2 * previewRefresh)
+	for range batch {
+		select {
+		case msg := <-msgs:
+			if _, ok := msg.(previewTickMsg); ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Init() with a query didn't schedule the preview refresh")
+		}
+	}
+	t.Fatal("Init() with a query didn't schedule the preview refresh")
+}
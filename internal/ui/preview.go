@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rmvaldesd/tplm/internal/config"
+	"github.com/rmvaldesd/tplm/internal/tmux"
+)
+
+// Preview layout settings.
+const (
+	previewRefresh         = time.Second
+	previewSideMinWidth    = 100 // narrower pickers show the preview below the list
+	previewListPercent     = 50  // share of the width the list keeps beside the preview
+	previewBottomPercent   = 40  // share of the height the preview takes below the list
+	previewBottomMinHeight = 20  // shorter pickers show no bottom preview
+	previewBorderWidth     = 2   // left border and padding of the side preview
+)
+
+// ansiReset ends any colors a captured line leaves open.
+const ansiReset = "\x1b[0m"
+
+// Preview keys for items that have nothing to capture.
+const (
+	previewKeyProject = "project:"
+	previewKeyNone    = "none"
+)
+
+// previewTickMsg asks the picker to recapture the preview.
+type previewTickMsg struct{}
+
+// previewTick schedules the next preview refresh.
+func previewTick() tea.Cmd {
+	return tea.Tick(previewRefresh, func(time.Time) tea.Msg { return previewTickMsg{} })
+}
+
+// previewSide reports whether the preview sits beside the list rather than below it.
+func (m PickerModel) previewSide() bool {
+	return m.width >= previewSideMinWidth
+}
+
+// previewBottomLines returns how many lines the preview takes below the list,
+// including its separator, or 0 when it is beside the list or hidden.
+func (m PickerModel) previewBottomLines() int {
+	if m.previewSide() || m.height < previewBottomMinHeight {
+		return 0
+	}
+	return m.height * previewBottomPercent / 100
+}
+
+// listWidth returns the width the list renders at when the picker is w wide.
+func (m PickerModel) listWidth(w int) int {
+	if m.previewSide() {
+		return w * previewListPercent / 100
+	}
+	return w
+}
+
+// refreshPreview recaptures the selected item's preview when the selection
+// changed, or always when force is set.
+func (m *PickerModel) refreshPreview(force bool) {
+	item := m.selectedItem()
+	if item == nil {
+		m.previewKey, m.preview = previewKeyNone, ""
+		return
+	}
+
+	client, target := m.previewTarget(item)
+	key := target
+	if target == "" {
+		key = previewKeyProject + item.name
+	}
+	if key == m.previewKey && !force {
+		return
+	}
+	m.previewKey = key
+
+	if target == "" {
+		m.preview = m.layoutPreview(m.cfg.FindProject(item.name))
+		return
+	}
+	out, err := client.CapturePane(target)
+	if err != nil {
+		m.preview = pathStyle.Render(fmt.Sprintf(MsgPreviewError, err))
+		return
+	}
+	m.preview = out
+}
+
+// previewTarget returns the client and tmux target whose active pane previews
+// item. The target is empty for a project without a running session.
+func (m *PickerModel) previewTarget(item *pickerItem) (*tmux.Client, string) {
+	switch {
+	case item.isWindow:
+		return m.client, fmt.Sprintf(tmux.FmtSessionWindow, item.sessionName, item.windowIndex)
+	case item.isSession:
+		return m.client, item.name
+	}
+	proj := m.cfg.FindProject(item.name)
+	if proj == nil {
+		return nil, ""
+	}
+	client := m.projectClient(proj)
+	if !client.SessionExists(proj.Name) {
+		return nil, ""
+	}
+	return client, proj.Name
+}
+
+// layoutPreview describes the session a project would create: its path and,
+// per window, the pane splits and commands.
+func (m PickerModel) layoutPreview(proj *config.Project) string {
+	if proj == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(pathStyle.Render(proj.Path) + "\n")
+	b.WriteString(pathStyle.Render(MsgPreviewNotRunning) + "\n\n")
+
+	for _, win := range m.cfg.GetLayout(proj).Windows {
+		b.WriteString(headerStyle.UnsetPaddingLeft().Render(win.Name) + "\n")
+		if win.Layout != "" {
+			b.WriteString("  " + fmt.Sprintf(MsgPreviewLayout, win.Layout) + "\n")
+		}
+		if win.Tree != nil {
+			pane := 0
+			writeTreePreview(&b, *win.Tree, "  ", &pane)
+		}
+		for i, pane := range win.Panes {
+			desc := fmt.Sprintf(MsgPreviewPane, i+1)
+			if i > 0 {
+				split := pane.Split
+				if split == "" {
+					split = MsgPreviewDefaultSplit
+				}
+				desc += " " + split
+				if pane.Size != "" {
+					desc += " " + pane.Size
+				}
+			}
+			if pane.Command != "" {
+				desc += "  " + pane.Command
+			}
+			b.WriteString("  " + desc + "\n")
+		}
+		for _, start := range proj.OnStart {
+			if start.Window == win.Name {
+				b.WriteString("  " + fmt.Sprintf(MsgPreviewOnStart, start.Command) + "\n")
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeTreePreview describes a pane tree, each split's children indented
+// under it. pane numbers the panes as in a panes list.
+func writeTreePreview(b *strings.Builder, n config.PaneNode, indent string, pane *int) {
+	desc := cmp.Or(n.Split, MsgPreviewDefaultSplit)
+	if len(n.Children) == 0 {
+		*pane++
+		desc = fmt.Sprintf(MsgPreviewPane, *pane)
+	}
+	if n.Size != "" {
+		desc += " " + n.Size
+	}
+	if n.Command != "" {
+		desc += "  " + n.Command
+	}
+	b.WriteString(indent + desc + "\n")
+	for _, child := range n.Children {
+		writeTreePreview(b, child, indent+"  ", pane)
+	}
+}
+
+// renderPreview fits the preview into a width × height box. Captured panes
+// keep their last lines, which is where a shell's latest output is.
+func (m PickerModel) renderPreview(width, height int) string {
+	lines := strings.Split(m.preview, "\n")
+	if strings.HasPrefix(m.previewKey, previewKeyProject) {
+		lines = lines[:min(len(lines), height)]
+	} else if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "") + ansiReset
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withPreview places the rendered list lines beside or above the preview.
+func (m PickerModel) withPreview(list []string, w int) []string {
+	if m.previewSide() {
+		lw := m.listWidth(w)
+		for i, line := range list {
+			list[i] = lipgloss.PlaceHorizontal(lw, lipgloss.Left, ansi.Truncate(line, lw, ""))
+		}
+		box := previewBorderStyle.Render(m.renderPreview(w-lw-previewBorderWidth, len(list)))
+		return strings.Split(lipgloss.JoinHorizontal(lipgloss.Top, strings.Join(list, "\n"), box), "\n")
+	}
+
+	h := m.previewBottomLines()
+	if h == 0 {
+		return list
+	}
+	list = append(list, separatorStyle.Render(strings.Repeat(SymbolSeparator, w)))
+	return append(list, strings.Split(m.renderPreview(w, h-1), "\n")...)
+}
@@ -45,6 +45,19 @@ var (
 			Bold(true).
 			Foreground(lipgloss.Color(ColorRed))
 
+	matchStyle = lipgloss.NewStyle().
+			Underline(true).
+			Foreground(lipgloss.Color(ColorMatch))
+
+	indicatorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorMuted)).
+			PaddingLeft(3)
+
+	previewBorderStyle = lipgloss.NewStyle().
+				Border(lipgloss.NormalBorder(), false, false, false, true).
+				BorderForeground(lipgloss.Color(ColorDim)).
+				PaddingLeft(1)
+
 	inputPromptStyle = lipgloss.NewStyle().
 				Bold(true).
 				Foreground(lipgloss.Color(ColorAccent)).
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Picker sections, in display order.
+const (
+	sectionProjects = iota
+	sectionSessions
+)
+
+// noItem marks body lines that are not a selectable item.
+const noItem = -1
+
+// sectionHeaderLines is the most header lines a section renders.
+const sectionHeaderLines = 3
+
+// titleLines is the height of the title bar rendered by titleBar.
+const titleLines = 2
+
+// bodyLine is one rendered line of the picker body.
+type bodyLine struct {
+	text    string
+	section int
+	header  bool // section header or separator, pinned while scrolling
+	item    int  // index into displayItems, or noItem
+}
+
+// bodyHeight returns how many lines the body may use, or 0 when the picker
+// size is unknown and the whole list is rendered.
+func (m PickerModel) bodyHeight() int {
+	if m.height <= 0 {
+		return 0
+	}
+	// The view ends in a newline, which the terminal counts as one more line.
+	h := m.height - titleLines - strings.Count(m.footer(), "\n") - m.previewBottomLines() - 1
+	return max(h, 1)
+}
+
+// visibleBody returns the body lines that fit in the picker at the current offset.
+func (m PickerModel) visibleBody(w int) []string {
+	lines := m.bodyLines(w)
+	h := m.bodyHeight()
+	if h == 0 {
+		out := make([]string, len(lines))
+		for i, l := range lines {
+			out[i] = l.text
+		}
+		return out
+	}
+	out, _ := viewport(lines, m.offset, h)
+	return out
+}
+
+// viewport renders at most height lines starting at line offset. The headers
+// of the section the first shown line belongs to are pinned at the top, and
+// "↑ N more" / "↓ N more" indicators replace lines when items are hidden. It
+// returns the rendered lines and the index of the last body line shown.
+func viewport(lines []bodyLine, offset, height int) ([]string, int) {
+	if len(lines) <= height {
+		out := make([]string, len(lines))
+		for i, l := range lines {
+			out[i] = l.text
+		}
+		return out, len(lines) - 1
+	}
+	offset = min(max(offset, 0), len(lines)-1)
+
+	var out []string
+	if above := countItems(lines[:offset]); above > 0 {
+		out = append(out, indicatorStyle.Render(fmt.Sprintf(MsgMoreAbove, above)))
+	}
+
+	// Pin the section headers when the section start has scrolled away.
+	section := lines[offset].section
+	for i := 0; i < offset; i++ {
+		if lines[i].section == section && lines[i].header {
+			out = append(out, lines[i].text)
+		}
+	}
+
+	last := offset - 1
+	for i := offset; i < len(lines); i++ {
+		room := height - len(out)
+		if i < len(lines)-1 {
+			room-- // keep a line for the "more below" indicator
+		}
+		if room <= 0 {
+			break
+		}
+		out = append(out, lines[i].text)
+		last = i
+	}
+
+	if below := countItems(lines[last+1:]); below > 0 {
+		out = append(out, indicatorStyle.Render(fmt.Sprintf(MsgMoreBelow, below)))
+	}
+	return out, last
+}
+
+func countItems(lines []bodyLine) int {
+	n := 0
+	for _, l := range lines {
+		if l.item != noItem {
+			n++
+		}
+	}
+	return n
+}
+
+// scrollToCursor adjusts the offset so the cursor's line is visible, scrolling
+// as little as possible, and pulls the view back when content shrinks.
+func (m *PickerModel) scrollToCursor() {
+	h := m.bodyHeight()
+	if h == 0 {
+		m.offset = 0
+		return
+	}
+	lines := m.bodyLines(DefaultPickerWidth)
+
+	target := -1
+	for i, l := range lines {
+		if l.item == m.cursor {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		m.offset = 0
+		return
+	}
+
+	if target < m.offset {
+		m.offset = target
+	}
+	// Show a section's headers in place rather than pinned when the cursor
+	// is on the section's first item.
+	for m.offset > 0 && lines[m.offset-1].header && lines[m.offset-1].section == lines[target].section {
+		m.offset--
+	}
+	for m.offset < target {
+		if _, last := viewport(lines, m.offset, h); last >= target {
+			break
+		}
+		m.offset++
+	}
+	// Don't leave blank space below the list after it shrinks.
+	for m.offset > 0 {
+		if _, last := viewport(lines, m.offset-1, h); last < len(lines)-1 || last < target {
+			break
+		}
+		m.offset--
+	}
+}
+
+// pageSize is how far PgUp/PgDn move the cursor.
+func (m PickerModel) pageSize() int {
+	h := m.bodyHeight()
+	if h == 0 {
+		return max(m.totalItems(), 1)
+	}
+	// Leave room for pinned headers and both indicators.
+	return max(h-sectionHeaderLines-2, 1)
+}
+
+// moveCursor moves the cursor by delta items, clamped to the list.
+func (m *PickerModel) moveCursor(delta int) {
+	m.cursor = min(max(m.cursor+delta, 0), max(m.totalItems()-1, 0))
+}